@@ -0,0 +1,177 @@
+package circleci
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var errBlankToken = errors.New("API token must not be blank")
+
+// Authenticator authenticates requests to CircleCI API.
+type Authenticator interface {
+	Authenticate(req *http.Request) error
+}
+
+// TokenSource provides API token. It is called for every request, thus can return rotated token.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// StaticToken is a TokenSource which always returns the same token.
+type StaticToken string
+
+// Token returns the token.
+func (t StaticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+// TokenSourceFunc is an adapter to use a function as TokenSource, e.g. to fetch token from a secret manager.
+type TokenSourceFunc func() (string, error)
+
+// Token returns the token by calling f.
+func (f TokenSourceFunc) Token() (string, error) {
+	return f()
+}
+
+// EnvToken returns TokenSource which reads token from the environment variable.
+func EnvToken(name string) TokenSource {
+	return TokenSourceFunc(func() (string, error) {
+		t := os.Getenv(name)
+		if t == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return t, nil
+	})
+}
+
+// FileToken returns TokenSource which reads token from the file.
+// The file is read every time, so the token can be rotated by replacing the file. Surrounding spaces are trimmed.
+func FileToken(path string) TokenSource {
+	return TokenSourceFunc(func() (string, error) {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(b)), nil
+	})
+}
+
+// CachedToken returns TokenSource which caches token given by src for ttl.
+// This can be used to avoid calling expensive source such as a secret manager for every request.
+func CachedToken(src TokenSource, ttl time.Duration) TokenSource {
+	return &cachedToken{src: src, ttl: ttl}
+}
+
+type cachedToken struct {
+	src TokenSource
+	ttl time.Duration
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func (c *cachedToken) Token() (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != "" && time.Now().Before(c.expires) {
+		return c.token, nil
+	}
+	t, err := c.src.Token()
+	if err != nil {
+		return "", err
+	}
+	c.token = t
+	c.expires = time.Now().Add(c.ttl)
+	return t, nil
+}
+
+// RotatingToken is a TokenSource whose token can be replaced at runtime.
+type RotatingToken struct {
+	mu    sync.RWMutex
+	token string
+}
+
+// NewRotatingToken creates RotatingToken with the initial token.
+func NewRotatingToken(token string) *RotatingToken {
+	return &RotatingToken{token: token}
+}
+
+// Token returns the current token.
+func (r *RotatingToken) Token() (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.token, nil
+}
+
+// Rotate replaces the token used for the following requests.
+func (r *RotatingToken) Rotate(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.token = token
+}
+
+func sourceToken(src TokenSource) (string, error) {
+	if src == nil {
+		return "", errBlankToken
+	}
+	t, err := src.Token()
+	if err != nil {
+		return "", fmt.Errorf("failed to get API token: %w", err)
+	}
+	if t == "" {
+		return "", errBlankToken
+	}
+	return t, nil
+}
+
+// APIKeyAuth authenticates requests with the token in Circle-Token header, i.e. api_key_header authentication.
+type APIKeyAuth struct {
+	Source TokenSource
+}
+
+// Authenticate sets the token to the request.
+func (a *APIKeyAuth) Authenticate(req *http.Request) error {
+	t, err := sourceToken(a.Source)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Circle-Token", t)
+	return nil
+}
+
+// BasicAuth authenticates requests with the token as the username of basic authentication, i.e. basic_auth authentication.
+type BasicAuth struct {
+	Source TokenSource
+}
+
+// Authenticate sets the token to the request.
+func (a *BasicAuth) Authenticate(req *http.Request) error {
+	t, err := sourceToken(a.Source)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t, "")
+	return nil
+}
+
+// BearerAuth authenticates requests with the token as OAuth bearer token in Authorization header.
+type BearerAuth struct {
+	Source TokenSource
+}
+
+// Authenticate sets the token to the request.
+func (a *BearerAuth) Authenticate(req *http.Request) error {
+	t, err := sourceToken(a.Source)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+t)
+	return nil
+}
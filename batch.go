@@ -0,0 +1,127 @@
+package circleci
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchConcurrency = 4
+	defaultRetryAfter       = time.Second
+)
+
+// ErrBatchAborted is set to the items not run because the Batch was aborted by a fatal error.
+var ErrBatchAborted = errors.New("batch aborted")
+
+// BatchFunc is a call run by Batch for the item of index i.
+type BatchFunc func(ctx context.Context, i int) (interface{}, error)
+
+// BatchResult represents the result of an item run by Batch.
+type BatchResult struct {
+	Index int
+	Value interface{}
+	Err   error
+}
+
+// Batch runs many API calls with bounded concurrency. Calls made through Client are subject to its rate limiting.
+type Batch struct {
+	// Concurrency is the maximum number of calls in flight (defaults to 4)
+	Concurrency int
+	// Retries is the number of retries of a call failed with 429 Too Many Requests
+	Retries int
+	// IsFatal reports whether the error aborts the batch (defaults to IsFatalError)
+	IsFatal func(error) bool
+}
+
+// IsFatalError reports whether the error is one every other call would fail with as well,
+// i.e. 401 Unauthorized or 403 Forbidden.
+func IsFatalError(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.HTTPStatusCode == http.StatusUnauthorized || apiErr.HTTPStatusCode == http.StatusForbidden
+}
+
+// Run runs fn for n items and returns their results in the order of items.
+// When an item fails with a fatal error, the items not started yet are aborted with ErrBatchAborted
+// and the fatal error is returned. Items not started when ctx is done get the error of ctx.
+func (b *Batch) Run(ctx context.Context, n int, fn BatchFunc) ([]BatchResult, error) {
+	concurrency := b.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	isFatal := b.IsFatal
+	if isFatal == nil {
+		isFatal = IsFatalError
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]BatchResult, n)
+	var (
+		mu    sync.Mutex
+		fatal error
+		wg    sync.WaitGroup
+	)
+	sem := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		results[i].Index = i
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			results[i].Err = ctx.Err()
+			mu.Lock()
+			if fatal != nil {
+				results[i].Err = ErrBatchAborted
+			}
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			v, err := b.call(ctx, i, fn)
+			results[i].Value, results[i].Err = v, err
+			if err != nil && isFatal(err) {
+				mu.Lock()
+				if fatal == nil {
+					fatal = err
+				}
+				mu.Unlock()
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return results, fatal
+}
+
+// call calls fn, retrying it when rate limited.
+func (b *Batch) call(ctx context.Context, i int, fn BatchFunc) (interface{}, error) {
+	for attempt := 0; ; attempt++ {
+		v, err := fn(ctx, i)
+		var apiErr *APIError
+		if attempt >= b.Retries || !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != http.StatusTooManyRequests {
+			return v, err
+		}
+
+		wait := defaultRetryAfter
+		if apiErr.Response != nil && apiErr.Response.RateLimit.RetryAfter > 0 {
+			wait = apiErr.Response.RateLimit.RetryAfter
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
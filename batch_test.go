@@ -0,0 +1,62 @@
+package circleci_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+func TestBatch_Run(t *testing.T) {
+	b := &circleci.Batch{Concurrency: 3}
+	results, err := b.Run(context.Background(), 10, func(ctx context.Context, i int) (interface{}, error) {
+		// Finish in the reverse order.
+		time.Sleep(time.Duration(10-i) * time.Millisecond)
+		if i == 5 {
+			return nil, errors.New("failed")
+		}
+		return i * i, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range results {
+		if r.Index != i {
+			t.Errorf("Invalid index. Expected: %d, Actual: %d", i, r.Index)
+		}
+		if i == 5 {
+			if r.Err == nil {
+				t.Error("Expected an error for item 5")
+			}
+			continue
+		}
+		if r.Err != nil || r.Value != i*i {
+			t.Errorf("Invalid result of item %d. Actual: %+v", i, r)
+		}
+	}
+}
+
+func TestBatch_Run_Fatal(t *testing.T) {
+	var calls int32
+	b := &circleci.Batch{Concurrency: 1}
+	results, err := b.Run(context.Background(), 5, func(ctx context.Context, i int) (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		if i == 1 {
+			return nil, &circleci.APIError{HTTPStatusCode: http.StatusUnauthorized}
+		}
+		return i, nil
+	})
+	if !circleci.IsFatalError(err) {
+		t.Fatalf("Expected a fatal error. Actual: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("Items after the fatal error must not run. Actual calls: %d", calls)
+	}
+	if results[0].Err != nil || !errors.Is(results[4].Err, circleci.ErrBatchAborted) {
+		t.Errorf("Invalid results. Actual: %+v", results)
+	}
+}
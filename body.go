@@ -0,0 +1,104 @@
+package circleci
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxBodySize = 64 << 20 // 64 MiB
+	maxErrorBodySize   = 1 << 20  // 1 MiB
+)
+
+// ErrBodyTooLarge is returned when a response body exceeds the maximum size. See WithMaxResponseBodySize.
+var ErrBodyTooLarge = errors.New("response body exceeds the maximum size")
+
+// limitBody returns a reader which fails with ErrBodyTooLarge when r has more than max bytes.
+// No limit is applied if max is not positive.
+func (c *Client) limitBody(r io.Reader, max int64) io.Reader {
+	if max <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, remaining: max}
+}
+
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining <= 0 {
+		// Check whether the body has more than the limit.
+		var b [1]byte
+		n, err := l.r.Read(b[:])
+		if n > 0 {
+			return 0, ErrBodyTooLarge
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.remaining {
+		p = p[:l.remaining]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	return n, err
+}
+
+// GetRaw performs a GET request for the given path and returns the response body as it is.
+// This can be used for large responses not to be decoded at once. The maximum body size is not applied.
+// The caller must close the body.
+func (c *Client) GetRaw(relPath string, options interface{}) (io.ReadCloser, *Response, error) {
+	relPath = path.Join(c.pathPrefix, strings.TrimLeft(relPath, "/"))
+	req, err := c.NewRequest("GET", relPath, nil, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.open(req)
+}
+
+// openURL performs a GET request for the given absolute URL and returns the response body as it is.
+// The request is authenticated only if authenticate is true, e.g. not for pre-signed URLs.
+func (c *Client) openURL(rawURL string, authenticate bool) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("User-Agent", UserAgent)
+	if authenticate && c.auth != nil {
+		if err := c.auth.Authenticate(req); err != nil {
+			return nil, err
+		}
+	}
+	body, _, err := c.open(req)
+	return body, err
+}
+
+// open executes a request with hooks and returns the response body open.
+func (c *Client) open(req *http.Request) (io.ReadCloser, *Response, error) {
+	if err := c.waitRateLimit(req.Context()); err != nil {
+		return nil, nil, err
+	}
+	for _, h := range c.hooks {
+		req = h.BeforeRequest(req)
+	}
+
+	start := time.Now()
+	resp, err := c.send(req)
+	if err == nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	elapsed := time.Since(start)
+
+	r := c.complete(req, resp, err, elapsed)
+	if err != nil {
+		return nil, r, err
+	}
+	return resp.Body, r, nil
+}
@@ -0,0 +1,193 @@
+package circleci
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const defaultCancelConcurrency = 4
+
+// Status of a workflow.
+const (
+	WorkflowStatusRunning = "running"
+	WorkflowStatusFailing = "failing"
+	WorkflowStatusOnHold  = "on_hold"
+)
+
+// CancelFilter specifies which workflows or jobs CancelAll cancels.
+type CancelFilter struct {
+	// ProjectSlug is the project to cancel workflows in. Required.
+	ProjectSlug string
+	// Branch limits to pipelines of the branch. All branches if empty.
+	Branch string
+	// MaxAge limits to pipelines created within the duration. No limit if zero.
+	MaxAge time.Duration
+	// MaxPipelines limits the number of recent pipelines to look into. Defaults to 100.
+	MaxPipelines int
+	// Statuses are the workflow statuses to cancel. Defaults to running, failing and on_hold.
+	Statuses []string
+	// Jobs makes running and queued jobs canceled one by one instead of their whole workflows.
+	Jobs bool
+	// Concurrency is the maximum number of cancel requests in flight. Defaults to 4.
+	Concurrency int
+	// DryRun makes CancelAll only report what would be canceled.
+	DryRun bool
+}
+
+// CancelResult represents result of canceling a workflow or a job.
+// JobNumber and JobName are set only when jobs are canceled.
+type CancelResult struct {
+	PipelineID     string `json:"pipeline_id,omitempty"`
+	PipelineNumber int    `json:"pipeline_number,omitempty"`
+	WorkflowID     string `json:"workflow_id,omitempty"`
+	WorkflowName   string `json:"workflow_name,omitempty"`
+	JobNumber      int    `json:"job_number,omitempty"`
+	JobName        string `json:"job_name,omitempty"`
+	Status         string `json:"status,omitempty"`
+	Canceled       bool   `json:"canceled"`
+	Error          string `json:"error,omitempty"`
+	Err            error  `json:"-"`
+}
+
+// CancelAll cancels workflows, or jobs in them, of recent pipelines matching the filter.
+// Errors of individual cancel requests are stored in Err and Error of each CancelResult,
+// while an error is returned when the targets could not be enumerated.
+func (c *Client) CancelAll(f *CancelFilter) ([]*CancelResult, error) {
+	if f == nil || f.ProjectSlug == "" {
+		return nil, errors.New("project slug is required to cancel workflows")
+	}
+	targets, err := c.cancelTargets(f)
+	if err != nil {
+		return nil, err
+	}
+	if f.DryRun {
+		return targets, nil
+	}
+
+	concurrency := f.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultCancelConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(t *CancelResult) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if t.JobNumber != 0 {
+				_, t.Err = c.Job.Cancel(strconv.Itoa(t.JobNumber), f.ProjectSlug)
+			} else {
+				_, t.Err = c.Workflow.Cancel(t.WorkflowID)
+			}
+			t.Canceled = t.Err == nil
+			if t.Err != nil {
+				t.Error = t.Err.Error()
+			}
+		}(t)
+	}
+	wg.Wait()
+	return targets, nil
+}
+
+func (c *Client) cancelTargets(f *CancelFilter) ([]*CancelResult, error) {
+	statuses := f.Statuses
+	if len(statuses) == 0 {
+		statuses = []string{WorkflowStatusRunning, WorkflowStatusFailing, WorkflowStatusOnHold}
+	}
+	maxPipelines := f.MaxPipelines
+	if maxPipelines <= 0 {
+		maxPipelines = queryLimit
+	}
+	var since time.Time
+	if f.MaxAge > 0 {
+		since = time.Now().Add(-f.MaxAge)
+	}
+
+	var targets []*CancelResult
+	opts := &PipelineListOptions{Branch: f.Branch}
+	seen := 0
+	for seen < maxPipelines {
+		pl, err := c.Pipeline.List(f.ProjectSlug, opts)
+		if err != nil {
+			return nil, err
+		}
+		for i := range pl.Items {
+			p := &pl.Items[i]
+			if seen >= maxPipelines || (!since.IsZero() && p.CreatedAt.Before(since)) {
+				return targets, nil
+			}
+			seen++
+			t, err := c.pipelineCancelTargets(p, statuses, f.Jobs)
+			if err != nil {
+				return nil, err
+			}
+			targets = append(targets, t...)
+		}
+		if pl.NextPageToken == "" {
+			break
+		}
+		opts.PageToken = pl.NextPageToken
+	}
+	return targets, nil
+}
+
+func (c *Client) pipelineCancelTargets(p *Pipeline, statuses []string, jobs bool) ([]*CancelResult, error) {
+	workflows, err := c.Pipeline.ListWorkflows(p.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []*CancelResult
+	for _, w := range workflows {
+		if !containsString(statuses, w.Status) {
+			continue
+		}
+		if !jobs {
+			targets = append(targets, &CancelResult{
+				PipelineID:     p.ID,
+				PipelineNumber: p.Number,
+				WorkflowID:     w.ID,
+				WorkflowName:   w.Name,
+				Status:         w.Status,
+			})
+			continue
+		}
+
+		wjs, err := c.Workflow.ListJobs(w.ID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range wjs {
+			j := &wjs[i]
+			s := jobStatus(j)
+			if j.JobNumber == 0 || (s != JobStatusRunning && s != JobStatusQueued) {
+				continue
+			}
+			targets = append(targets, &CancelResult{
+				PipelineID:     p.ID,
+				PipelineNumber: p.Number,
+				WorkflowID:     w.ID,
+				WorkflowName:   w.Name,
+				JobNumber:      j.JobNumber,
+				JobName:        j.Name,
+				Status:         s,
+			})
+		}
+	}
+	return targets, nil
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
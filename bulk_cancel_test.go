@@ -0,0 +1,233 @@
+package circleci_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+// cancelServer serves pipelines p1 and p2 created recently and p3 created two days ago, on two pages.
+// Each pipeline has a running, a successful and an on_hold workflow, and the running one has a running,
+// a queued and a successful job.
+type cancelServer struct {
+	mu       sync.Mutex
+	branches []string
+	canceled []string
+	inFlight int
+	maxIn    int
+}
+
+func (s *cancelServer) handler(t *testing.T) http.Handler {
+	now := time.Now()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/project/gh/org/repo/pipeline", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.branches = append(s.branches, r.URL.Query().Get("branch"))
+		s.mu.Unlock()
+		if r.URL.Query().Get("page-token") == "" {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"items": []map[string]interface{}{
+					{"id": "p1", "number": 1, "created_at": now.Add(-time.Hour)},
+					{"id": "p2", "number": 2, "created_at": now.Add(-2 * time.Hour)},
+				},
+				"next_page_token": "next",
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"items": []map[string]interface{}{{"id": "p3", "number": 3, "created_at": now.Add(-48 * time.Hour)}},
+		})
+	})
+	for _, p := range []string{"p1", "p2", "p3"} {
+		p := p
+		mux.HandleFunc("/api/v2/pipeline/"+p+"/workflow", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"items": [{"id": "%[1]s-running", "status": "running"}, {"id": "%[1]s-success", "status": "success"}, {"id": "%[1]s-hold", "status": "on_hold"}]}`, p)
+		})
+		mux.HandleFunc("/api/v2/workflow/"+p+"-running/job", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"items": [{"job_number": %[1]d1, "status": "running"}, {"job_number": %[1]d2, "status": "queued"}, {"job_number": %[1]d3, "status": "success"}]}`, p[1]-'0')
+		})
+		mux.HandleFunc("/api/v2/workflow/"+p+"-hold/job", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"items": [{"name": "hold", "type": "approval", "status": "on_hold"}]}`)
+		})
+	}
+	mux.HandleFunc("/api/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || !strings.HasSuffix(r.URL.Path, "/cancel") {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+			http.NotFound(w, r)
+			return
+		}
+		s.mu.Lock()
+		s.canceled = append(s.canceled, strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/api/v2/"), "/cancel"))
+		s.inFlight++
+		if s.inFlight > s.maxIn {
+			s.maxIn = s.inFlight
+		}
+		s.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+		fmt.Fprint(w, `{"message": "Accepted."}`)
+	})
+	return mux
+}
+
+func (s *cancelServer) canceledPaths() string {
+	sort.Strings(s.canceled)
+	return strings.Join(s.canceled, ",")
+}
+
+func TestClient_CancelAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   circleci.CancelFilter
+		expected string
+	}{
+		{
+			name:     "default statuses",
+			filter:   circleci.CancelFilter{},
+			expected: "workflow/p1-hold,workflow/p1-running,workflow/p2-hold,workflow/p2-running,workflow/p3-hold,workflow/p3-running",
+		},
+		{
+			name:     "max age",
+			filter:   circleci.CancelFilter{MaxAge: 24 * time.Hour},
+			expected: "workflow/p1-hold,workflow/p1-running,workflow/p2-hold,workflow/p2-running",
+		},
+		{
+			name:     "max pipelines",
+			filter:   circleci.CancelFilter{MaxPipelines: 1},
+			expected: "workflow/p1-hold,workflow/p1-running",
+		},
+		{
+			name:     "statuses",
+			filter:   circleci.CancelFilter{Statuses: []string{"on_hold"}, MaxPipelines: 2},
+			expected: "workflow/p1-hold,workflow/p2-hold",
+		},
+		{
+			name:     "jobs",
+			filter:   circleci.CancelFilter{Jobs: true, MaxPipelines: 1},
+			expected: "project/gh/org/repo/job/11,project/gh/org/repo/job/12",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &cancelServer{}
+			c := newTestClient(t, s.handler(t))
+			f := tt.filter
+			f.ProjectSlug = "gh/org/repo"
+
+			results, err := c.CancelAll(&f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := s.canceledPaths(); actual != tt.expected {
+				t.Errorf("Invalid cancel requests.\nExpected: %s\nActual:   %s", tt.expected, actual)
+			}
+			if len(results) != len(s.canceled) {
+				t.Errorf("Expected %d results. Actual: %d", len(s.canceled), len(results))
+			}
+			for _, r := range results {
+				if !r.Canceled || r.Err != nil {
+					t.Errorf("Result must be canceled. Actual: %+v", r)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_CancelAll_Branch(t *testing.T) {
+	s := &cancelServer{}
+	c := newTestClient(t, s.handler(t))
+
+	_, err := c.CancelAll(&circleci.CancelFilter{ProjectSlug: "gh/org/repo", Branch: "feature", DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.branches) != 2 || s.branches[0] != "feature" || s.branches[1] != "feature" {
+		t.Errorf("Branch must be given to every page. Actual: %v", s.branches)
+	}
+}
+
+func TestClient_CancelAll_DryRun(t *testing.T) {
+	s := &cancelServer{}
+	c := newTestClient(t, s.handler(t))
+
+	results, err := c.CancelAll(&circleci.CancelFilter{ProjectSlug: "gh/org/repo", Jobs: true, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.canceled) != 0 {
+		t.Errorf("Dry run must not cancel anything. Actual: %v", s.canceled)
+	}
+	if len(results) != 6 {
+		t.Fatalf("Expected 6 jobs to cancel. Actual: %d", len(results))
+	}
+	if r := results[0]; r.JobNumber != 11 || r.WorkflowID != "p1-running" || r.Status != "running" || r.Canceled {
+		t.Errorf("Invalid result. Actual: %+v", r)
+	}
+}
+
+func TestClient_CancelAll_Concurrency(t *testing.T) {
+	s := &cancelServer{}
+	c := newTestClient(t, s.handler(t))
+
+	_, err := c.CancelAll(&circleci.CancelFilter{ProjectSlug: "gh/org/repo", Jobs: true, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.canceled) != 6 {
+		t.Errorf("Expected 6 cancel requests. Actual: %d", len(s.canceled))
+	}
+	if s.maxIn > 2 {
+		t.Errorf("At most 2 requests must be in flight. Actual: %d", s.maxIn)
+	}
+}
+
+func TestClient_CancelAll_InvalidFilter(t *testing.T) {
+	c := newTestClient(t, http.NotFoundHandler())
+	if _, err := c.CancelAll(nil); err == nil {
+		t.Error("Expected an error for nil filter")
+	}
+	if _, err := c.CancelAll(&circleci.CancelFilter{}); err == nil {
+		t.Error("Expected an error for empty project slug")
+	}
+}
+
+func TestClient_CancelAll_Error(t *testing.T) {
+	s := &cancelServer{}
+	h := s.handler(t)
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v2/project/gh/org/repo/job/12/cancel" {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "Permission denied"}`)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}))
+
+	results, err := c.CancelAll(&circleci.CancelFilter{ProjectSlug: "gh/org/repo", Jobs: true, MaxPipelines: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results. Actual: %d", len(results))
+	}
+	r := results[1]
+	if r.Canceled || r.Err == nil || !strings.Contains(r.Error, "Permission denied") {
+		t.Errorf("Result must have the error. Actual: %+v", r)
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"error":`) {
+		t.Errorf("JSON must have the error. Actual: %s", b)
+	}
+}
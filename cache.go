@@ -0,0 +1,281 @@
+package circleci
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"path"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const defaultImmutableCacheTTL = 24 * time.Hour
+
+// finishedStatuses are the statuses of workflows and jobs which will not change any more.
+var finishedStatuses = map[string]bool{
+	"success":             true,
+	"failed":              true,
+	"error":               true,
+	"canceled":            true,
+	"not_run":             true,
+	"infrastructure_fail": true,
+	"timedout":            true,
+	"terminated-unknown":  true,
+	"unauthorized":        true,
+}
+
+// CacheEntry represents a cached response.
+type CacheEntry struct {
+	Body         []byte
+	ETag         string
+	LastModified string
+	Expires      time.Time
+	// Immutable is true when the resource is finished, e.g. a finished workflow or job.
+	Immutable bool
+}
+
+// CacheStorage stores CacheEntry. Implementations must be safe for concurrent use.
+// Get should return expired entries as well, as they are used for conditional requests.
+type CacheStorage interface {
+	Get(key string) (*CacheEntry, bool)
+	Set(key string, entry *CacheEntry)
+	Delete(key string)
+}
+
+// CacheStats represents statistics of Cache.
+type CacheStats struct {
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Revalidated uint64 `json:"revalidated"`
+}
+
+// Cache caches responses of GET requests.
+// Immutable resources such as finished workflows and jobs are cached for ImmutableTTL, and others for TTL.
+// Expired entries are revalidated with conditional requests if the response had ETag or Last-Modified header.
+// Entries of a resource and its parent list are evicted when the client sends POST, PUT, PATCH or DELETE to it.
+type Cache struct {
+	// Counters are placed first to be 64-bit aligned for atomic operations.
+	hits        uint64
+	misses      uint64
+	revalidated uint64
+
+	Storage CacheStorage
+	// TTL of mutable resources (defaults to 0). Zero or negative value makes them always revalidated,
+	// and they are not cached at all without ETag or Last-Modified header. Set it only if stale responses are acceptable,
+	// since polling methods such as JobLogService.Tail and UsageService.WaitExport would see them.
+	TTL time.Duration
+	// ImmutableTTL of immutable resources (defaults to 24 hours)
+	ImmutableTTL time.Duration
+	// ImmutableOnly makes only immutable resources cached
+	ImmutableOnly bool
+}
+
+// NewCache creates Cache backed by LRUCache of the capacity with default TTLs.
+func NewCache(capacity int) *Cache {
+	return &Cache{
+		Storage:      NewLRUCache(capacity),
+		ImmutableTTL: defaultImmutableCacheTTL,
+	}
+}
+
+// Stats returns the statistics of the cache.
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{
+		Hits:        atomic.LoadUint64(&c.hits),
+		Misses:      atomic.LoadUint64(&c.misses),
+		Revalidated: atomic.LoadUint64(&c.revalidated),
+	}
+}
+
+// lookup returns the entry of the key and whether it is fresh.
+// A stale entry is returned to make a conditional request.
+func (c *Cache) lookup(key string) (*CacheEntry, bool) {
+	entry, ok := c.Storage.Get(key)
+	if !ok {
+		atomic.AddUint64(&c.misses, 1)
+		return nil, false
+	}
+	if time.Now().Before(entry.Expires) {
+		atomic.AddUint64(&c.hits, 1)
+		return entry, true
+	}
+	atomic.AddUint64(&c.misses, 1)
+	if entry.ETag == "" && entry.LastModified == "" {
+		c.Storage.Delete(key)
+		return nil, false
+	}
+	return entry, false
+}
+
+func (c *Cache) store(key string, resp *http.Response, body []byte) {
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+	immutable := isFinished(body)
+	if !immutable && c.ImmutableOnly {
+		return
+	}
+	entry := &CacheEntry{
+		Body:         body,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Immutable:    immutable,
+	}
+	if !immutable && c.TTL <= 0 && entry.ETag == "" && entry.LastModified == "" {
+		// It could never be served without revalidation.
+		return
+	}
+	entry.Expires = time.Now().Add(c.ttl(immutable))
+	c.Storage.Set(key, entry)
+}
+
+// invalidate evicts the entries of the resource the request modifies and its parent list, e.g. the list of
+// environment variables for a request creating one. Entries of the parent list with queries are left to expire.
+func (c *Cache) invalidate(req *http.Request) {
+	u := *req.URL
+	u.RawQuery = ""
+	u.Fragment = ""
+	for _, p := range []string{u.Path, path.Dir(u.Path)} {
+		u.Path = p
+		r := &http.Request{URL: &u, Header: req.Header}
+		c.Storage.Delete(cacheKey(r))
+	}
+}
+
+func (c *Cache) revalidate(key string, entry *CacheEntry, resp *http.Response) {
+	atomic.AddUint64(&c.revalidated, 1)
+	renewed := *entry
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		renewed.ETag = etag
+	}
+	renewed.Expires = time.Now().Add(c.ttl(entry.Immutable))
+	c.Storage.Set(key, &renewed)
+}
+
+func (c *Cache) ttl(immutable bool) time.Duration {
+	if immutable {
+		if c.ImmutableTTL <= 0 {
+			return defaultImmutableCacheTTL
+		}
+		return c.ImmutableTTL
+	}
+	return c.TTL
+}
+
+// withoutConditionalHeaders returns a copy of the request without headers which make it conditional.
+func withoutConditionalHeaders(req *http.Request) *http.Request {
+	r := req.Clone(req.Context())
+	r.Header.Del("If-None-Match")
+	r.Header.Del("If-Modified-Since")
+	return r
+}
+
+func setConditionalHeaders(req *http.Request, entry *CacheEntry) {
+	if entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.LastModified)
+	}
+}
+
+// cacheKey makes a key from the URL and credentials of the request,
+// so responses are not shared among clients of different users.
+func cacheKey(req *http.Request) string {
+	h := sha256.New()
+	h.Write([]byte(req.Header.Get("Circle-Token")))
+	h.Write([]byte{0})
+	h.Write([]byte(req.Header.Get("Authorization")))
+	return req.URL.String() + "#" + hex.EncodeToString(h.Sum(nil))
+}
+
+// isFinished reports whether the body is a finished workflow or job, which will not change any more.
+func isFinished(body []byte) bool {
+	var r struct {
+		Status    interface{} `json:"status"`
+		StoppedAt string      `json:"stopped_at"`
+		Lifecycle string      `json:"lifecycle"`
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return false
+	}
+	if r.Lifecycle == lifecycleFinished {
+		return true
+	}
+	status, ok := r.Status.(string)
+	return ok && r.StoppedAt != "" && finishedStatuses[status]
+}
+
+// LRUCache is an in-memory CacheStorage which evicts the least recently used entry when it exceeds the capacity.
+type LRUCache struct {
+	capacity int
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+var _ CacheStorage = (*LRUCache)(nil)
+
+type lruItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewLRUCache creates LRUCache with the capacity.
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    map[string]*list.Element{},
+	}
+}
+
+// Get gets the entry of the key.
+func (l *LRUCache) Get(key string) (*CacheEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	e, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	l.ll.MoveToFront(e)
+	return e.Value.(*lruItem).entry, true
+}
+
+// Set sets the entry of the key.
+func (l *LRUCache) Set(key string, entry *CacheEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.items[key]; ok {
+		e.Value.(*lruItem).entry = entry
+		l.ll.MoveToFront(e)
+		return
+	}
+	l.items[key] = l.ll.PushFront(&lruItem{key: key, entry: entry})
+	for l.capacity > 0 && l.ll.Len() > l.capacity {
+		oldest := l.ll.Back()
+		l.ll.Remove(oldest)
+		delete(l.items, oldest.Value.(*lruItem).key)
+	}
+}
+
+// Delete deletes the entry of the key.
+func (l *LRUCache) Delete(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.items[key]; ok {
+		l.ll.Remove(e)
+		delete(l.items, key)
+	}
+}
+
+// Len returns the number of entries.
+func (l *LRUCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ll.Len()
+}
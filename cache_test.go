@@ -0,0 +1,189 @@
+package circleci_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+func TestCache(t *testing.T) {
+	var workflowCalls, projectCalls, notModified int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/workflow/wf", func(w http.ResponseWriter, r *http.Request) {
+		workflowCalls++
+		fmt.Fprint(w, `{"id": "wf", "status": "success", "stopped_at": "2021-01-01T00:00:00Z"}`)
+	})
+	mux.HandleFunc("/api/v2/project/gh/ttyfky/go-circleci", func(w http.ResponseWriter, r *http.Request) {
+		projectCalls++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{"slug": "gh/ttyfky/go-circleci"}`)
+	})
+
+	cache := circleci.NewCache(10)
+	cache.TTL = 0
+	c := newTestClient(t, mux)
+	circleci.WithCache(cache)(c)
+
+	for i := 0; i < 2; i++ {
+		w, err := c.Workflow.Get("wf")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.Status != "success" {
+			t.Errorf("Invalid status. Expected: success, Actual: %s", w.Status)
+		}
+
+		p, err := c.Project.Get("gh/ttyfky/go-circleci")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Slug != "gh/ttyfky/go-circleci" {
+			t.Errorf("Invalid slug. Actual: %s", p.Slug)
+		}
+	}
+
+	if workflowCalls != 1 {
+		t.Errorf("Finished workflow must be cached. Actual calls: %d", workflowCalls)
+	}
+	if projectCalls != 2 || notModified != 1 {
+		t.Errorf("Project must be revalidated. Actual calls: %d, not modified: %d", projectCalls, notModified)
+	}
+	expected := circleci.CacheStats{Hits: 1, Misses: 3, Revalidated: 1}
+	if stats := cache.Stats(); stats != expected {
+		t.Errorf("Invalid stats. Expected: %+v, Actual: %+v", expected, stats)
+	}
+}
+
+func TestCache_Mutable(t *testing.T) {
+	var listCalls int
+	envVars := `{"items": [{"name": "A"}]}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/project/gh/ttyfky/go-circleci/envvar", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			envVars = `{"items": [{"name": "A"}, {"name": "B"}]}`
+			fmt.Fprint(w, `{"name": "B"}`)
+			return
+		}
+		listCalls++
+		fmt.Fprint(w, envVars)
+	})
+	mux.HandleFunc("/api/v2/workflow/wf", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "wf", "status": "running"}`)
+	})
+
+	cache := circleci.NewCache(10)
+	c := newTestClient(t, mux)
+	circleci.WithCache(cache)(c)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.EnvVar.List("gh/ttyfky/go-circleci"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if listCalls != 2 {
+		t.Errorf("Mutable resources must not be cached by default. Actual calls: %d", listCalls)
+	}
+
+	cache.TTL = time.Hour
+	if _, err := c.EnvVar.List("gh/ttyfky/go-circleci"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.EnvVar.Create("gh/ttyfky/go-circleci", "B", "b"); err != nil {
+		t.Fatal(err)
+	}
+	l, err := c.EnvVar.List("gh/ttyfky/go-circleci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Items) != 2 || listCalls != 4 {
+		t.Errorf("The list must be evicted after creating a variable. Actual: %d items, %d calls", len(l.Items), listCalls)
+	}
+}
+
+func TestCache_Hooks(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/workflow/wf", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "wf", "status": "success", "stopped_at": "2021-01-01T00:00:00Z"}`)
+	})
+	var before, after int
+	c := newTestClient(t, mux)
+	circleci.WithCache(circleci.NewCache(10))(c)
+	circleci.WithHook(&circleci.HookFuncs{
+		Before: func(req *http.Request) *http.Request { before++; return req },
+		After:  func(*http.Request, *http.Response, time.Duration) { after++ },
+	})(c)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Workflow.Get("wf"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if before != 2 || after != 2 || !c.LastResponse().Cached {
+		t.Errorf("Hooks must be called on cache hits. Actual: before %d, after %d", before, after)
+	}
+}
+
+func TestCache_ExternalURL(t *testing.T) {
+	calls := 0
+	s3 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("ETag", `"output"`)
+		w.Header().Set("Cache-Control", "max-age=3600")
+		fmt.Fprint(w, `[{"message": "hello\n"}]`)
+	}))
+	t.Cleanup(s3.Close)
+
+	cache := circleci.NewCache(10)
+	c := newTestClient(t, http.NotFoundHandler())
+	circleci.WithCache(cache)(c)
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.JobLog.Output(&circleci.StepAction{HasOutput: true, OutputURL: s3.URL + "/output?X-Amz-Signature=sig"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls != 2 {
+		t.Errorf("Pre-signed URL must be requested every time. Actual calls: %d", calls)
+	}
+	if stats := cache.Stats(); stats != (circleci.CacheStats{}) {
+		t.Errorf("Pre-signed URL must bypass the cache. Actual: %+v", stats)
+	}
+}
+
+func TestCache_NotModifiedWithoutEntry(t *testing.T) {
+	calls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/project/gh/ttyfky/go-circleci", func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("If-None-Match") != "" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, `{"slug": "gh/ttyfky/go-circleci"}`)
+	})
+	c := newTestClient(t, mux)
+	circleci.WithCache(circleci.NewCache(10))(c)
+	// The hook makes requests conditional on a response which is not in the cache, as if it was evicted.
+	circleci.WithHook(&circleci.HookFuncs{Before: func(req *http.Request) *http.Request {
+		req.Header.Set("If-None-Match", `"v0"`)
+		return req
+	}})(c)
+
+	p, err := c.Project.Get("gh/ttyfky/go-circleci")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Slug != "gh/ttyfky/go-circleci" || calls != 2 {
+		t.Errorf("Response must be requested again in full. Actual: %+v, calls: %d", p, calls)
+	}
+}
@@ -0,0 +1,191 @@
+// Command circleci-config works on CircleCI config offline.
+//
+// Usage:
+//
+//	circleci-config validate [-format text|json] [file]
+//	circleci-config process [-branch name] [-tag name] [-param name=value]... [file]
+//	circleci-config predict [-branch name] [-tag name] [-param name=value]... [file]
+//
+// The file defaults to .circleci/config.yml.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ttyfky/go-circleci/config"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultConfigPath = ".circleci/config.yml"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	switch args[0] {
+	case "validate":
+		return validate(args[1:], stdout, stderr)
+	case "process":
+		return process(args[1:], stdout, stderr)
+	case "predict":
+		return predict(args[1:], stdout, stderr)
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: circleci-config validate [-format text|json] [file]")
+	fmt.Fprintln(w, "       circleci-config process [-branch name] [-tag name] [-param name=value]... [file]")
+	fmt.Fprintln(w, "       circleci-config predict [-branch name] [-tag name] [-param name=value]... [file]")
+}
+
+// configPath returns the path given to the subcommand or the default one.
+func configPath(fs *flag.FlagSet) string {
+	if fs.NArg() > 0 {
+		return fs.Arg(0)
+	}
+	return defaultConfigPath
+}
+
+func validate(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "text", "output format, text or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	c, err := config.ParseFile(configPath(fs))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	diagnostics := config.Validate(c)
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if diagnostics == nil {
+			diagnostics = []config.Diagnostic{}
+		}
+		if err := enc.Encode(diagnostics); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	case "text":
+		for _, d := range diagnostics {
+			fmt.Fprintln(stdout, d)
+		}
+	default:
+		fmt.Fprintf(stderr, "unknown format %q\n", *format)
+		return 2
+	}
+	if config.HasErrors(diagnostics) {
+		return 1
+	}
+	return 0
+}
+
+// parameters collects pipeline parameters given as name=value. Values are parsed as YAML, e.g. true is boolean.
+type parameters map[string]interface{}
+
+func (p parameters) String() string {
+	return fmt.Sprint(map[string]interface{}(p))
+}
+
+func (p parameters) Set(s string) error {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return fmt.Errorf("parameter must be name=value: %s", s)
+	}
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(s[i+1:]), &v); err != nil {
+		return err
+	}
+	p[s[:i]] = v
+	return nil
+}
+
+// pipelineFlags parses flags of the pipeline to process the config for, and returns the path of the config.
+func pipelineFlags(name string, args []string, stderr io.Writer) (*config.ProcessOptions, string, error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	opts := &config.ProcessOptions{Parameters: parameters{}}
+	fs.StringVar(&opts.Branch, "branch", "", "branch of the pipeline")
+	fs.StringVar(&opts.Tag, "tag", "", "tag of the pipeline")
+	fs.Var(parameters(opts.Parameters), "param", "pipeline parameter as name=value, can be repeated")
+	if err := fs.Parse(args); err != nil {
+		return nil, "", err
+	}
+	return opts, configPath(fs), nil
+}
+
+func process(args []string, stdout, stderr io.Writer) int {
+	opts, path, err := pipelineFlags("process", args, stderr)
+	if err != nil {
+		return 2
+	}
+
+	c, err := config.ParseFile(path)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	compiled, err := config.Process(c, opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	out, err := compiled.Marshal()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	stdout.Write(out)
+	return 0
+}
+
+func predict(args []string, stdout, stderr io.Writer) int {
+	opts, path, err := pipelineFlags("predict", args, stderr)
+	if err != nil {
+		return 2
+	}
+
+	c, err := config.ParseFile(path)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	workflows, err := config.Predict(c, opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	for _, w := range workflows {
+		fmt.Fprintf(stdout, "%s: %s\n", w.Name, runs(w.Run, w.Reason))
+		for _, j := range w.Jobs {
+			fmt.Fprintf(stdout, "  %s: %s\n", j.Name, runs(j.Run, j.Reason))
+		}
+	}
+	return 0
+}
+
+func runs(run bool, reason string) string {
+	if run {
+		return "runs"
+	}
+	return "skipped (" + reason + ")"
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const validConfig = `version: 2.1
+parameters:
+  deploy:
+    type: boolean
+    default: false
+jobs:
+  build:
+    docker:
+      - image: cimg/go:1.16
+    steps:
+      - checkout
+workflows:
+  main:
+    jobs:
+      - build
+  deploy:
+    when: << pipeline.parameters.deploy >>
+    jobs:
+      - build
+`
+
+const invalidConfig = `version: 2.1
+jobs:
+  build:
+    docker:
+      - image: cimg/go:1.16
+    steps:
+      - checkout
+workflows:
+  main:
+    jobs:
+      - build
+      - deploy
+`
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	valid, invalid := filepath.Join(dir, "valid.yml"), filepath.Join(dir, "invalid.yml")
+	if err := ioutil.WriteFile(valid, []byte(validConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(invalid, []byte(invalidConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{
+			name:   "no command",
+			code:   2,
+			stderr: "usage:",
+		},
+		{
+			name:   "unknown command",
+			args:   []string{"lint"},
+			code:   2,
+			stderr: `unknown command "lint"`,
+		},
+		{
+			name: "validate valid",
+			args: []string{"validate", valid},
+		},
+		{
+			name:   "validate invalid",
+			args:   []string{"validate", invalid},
+			code:   1,
+			stdout: invalid + ":12:9: error: job deploy is not defined\n",
+		},
+		{
+			name:   "validate valid in json",
+			args:   []string{"validate", "-format", "json", valid},
+			stdout: "[]\n",
+		},
+		{
+			name: "validate invalid in json",
+			args: []string{"validate", "-format", "json", invalid},
+			code: 1,
+			stdout: `[
+  {
+    "file": "` + invalid + `",
+    "line": 12,
+    "column": 9,
+    "severity": "error",
+    "message": "job deploy is not defined"
+  }
+]
+`,
+		},
+		{
+			name:   "validate in unknown format",
+			args:   []string{"validate", "-format", "xml", valid},
+			code:   2,
+			stderr: `unknown format "xml"`,
+		},
+		{
+			name:   "validate missing file",
+			args:   []string{"validate", filepath.Join(dir, "missing.yml")},
+			code:   1,
+			stderr: "missing.yml",
+		},
+		{
+			name:   "validate unknown flag",
+			args:   []string{"validate", "-strict", valid},
+			code:   2,
+			stderr: "flag provided but not defined: -strict",
+		},
+		{
+			name:   "process",
+			args:   []string{"process", "-branch", "main", valid},
+			stdout: "workflows:\n  main:\n",
+		},
+		{
+			name:   "process undefined job",
+			args:   []string{"process", invalid},
+			code:   1,
+			stderr: "job deploy is not defined",
+		},
+		{
+			name:   "process invalid param",
+			args:   []string{"process", "-param", "deploy", valid},
+			code:   2,
+			stderr: "parameter must be name=value",
+		},
+		{
+			name:   "predict",
+			args:   []string{"predict", "-param", "deploy=false", valid},
+			stdout: "deploy: skipped (",
+		},
+		{
+			name:   "predict with param",
+			args:   []string{"predict", "-param", "deploy=true", valid},
+			stdout: "deploy: runs\n  build: runs\nmain: runs\n  build: runs\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(tt.args, &stdout, &stderr)
+			if code != tt.code {
+				t.Errorf("Invalid exit code. Expected: %d, Actual: %d\nstderr: %s", tt.code, code, stderr.String())
+			}
+			if !strings.Contains(stdout.String(), tt.stdout) || (tt.stdout == "" && stdout.Len() != 0) {
+				t.Errorf("Invalid stdout.\nExpected: %q\nActual:   %q", tt.stdout, stdout.String())
+			}
+			if !strings.Contains(stderr.String(), tt.stderr) {
+				t.Errorf("Invalid stderr.\nExpected: %q\nActual:   %q", tt.stderr, stderr.String())
+			}
+		})
+	}
+}
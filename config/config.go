@@ -0,0 +1,113 @@
+// Package config parses CircleCI configuration, i.e. .circleci/config.yml, into typed structs.
+//
+// Positions in the source are kept in each element for error messages, and the config can be
+// marshaled back to YAML. Keys of maps are sorted in the marshaled YAML.
+// Keys which are not modeled, e.g. ones added to CircleCI config later, are kept in Extra of
+// the element and written back as they are.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config represents a CircleCI config of version 2.1.
+type Config struct {
+	Pos        `yaml:"-"`
+	Version    Scalar                 `yaml:"version"`
+	Setup      bool                   `yaml:"setup,omitempty"`
+	Orbs       map[string]*Orb        `yaml:"orbs,omitempty"`
+	Parameters map[string]*Parameter  `yaml:"parameters,omitempty"`
+	Executors  map[string]*Executor   `yaml:"executors,omitempty"`
+	Commands   map[string]*Command    `yaml:"commands,omitempty"`
+	Jobs       map[string]*Job        `yaml:"jobs,omitempty"`
+	Workflows  Workflows              `yaml:"workflows,omitempty"`
+	Extra      map[string]interface{} `yaml:",inline"`
+
+	// File is the path of the config if parsed by ParseFile.
+	File string `yaml:"-"`
+}
+
+// Orb represents an orb imported by reference such as circleci/node@5.0.0, or defined inline.
+type Orb struct {
+	Pos    `yaml:"-"`
+	Ref    string
+	Inline *Config
+}
+
+// Parse parses the config.
+func Parse(data []byte) (*Config, error) {
+	c := &Config{}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ParseFile reads and parses the config file.
+func ParseFile(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := Parse(data)
+	if err != nil {
+		var e *Error
+		if errors.As(err, &e) {
+			e.File = path
+			return nil, e
+		}
+		return nil, &Error{File: path, Message: err.Error()}
+	}
+	c.File = path
+	return c, nil
+}
+
+// Marshal returns the config in YAML.
+func (c *Config) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (c *Config) UnmarshalYAML(n *yaml.Node) error {
+	type plain Config
+	if err := n.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	c.Pos = posOf(n)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (o *Orb) UnmarshalYAML(n *yaml.Node) error {
+	o.Pos = posOf(n)
+	switch n.Kind {
+	case yaml.ScalarNode:
+		o.Ref = n.Value
+		return nil
+	case yaml.MappingNode:
+		o.Inline = &Config{}
+		return n.Decode(o.Inline)
+	}
+	return errorf(n, "orb must be a reference or an inline orb")
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (o *Orb) MarshalYAML() (interface{}, error) {
+	if o.Inline != nil {
+		return o.Inline, nil
+	}
+	return o.Ref, nil
+}
@@ -0,0 +1,195 @@
+package config_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ttyfky/go-circleci/config"
+)
+
+func TestParseFile(t *testing.T) {
+	c, err := config.ParseFile("testdata/config.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Version.Value != "2.1" || c.Orbs["node"].Ref != "circleci/node@5.0.2" {
+		t.Errorf("Invalid version or orbs. Actual: %s, %+v", c.Version, c.Orbs["node"])
+	}
+	if p := c.Parameters["deploy"]; p.Type != config.ParameterBoolean || p.Default != false {
+		t.Errorf("Invalid parameter. Actual: %+v", p)
+	}
+
+	build := c.Jobs["build"]
+	if build.Line != 38 || build.Executor.Name != "go" || build.Executor.Parameters["version"] != "1.16" || build.Parallelism.Value != "2" {
+		t.Errorf("Invalid job. Actual: %+v", build)
+	}
+	if s := build.Steps[1]; s.Name != "test" || !reflect.DeepEqual(s.Args, map[string]interface{}{"race": true}) || s.Line != 44 {
+		t.Errorf("Invalid step. Actual: %+v", s)
+	}
+	if c.Jobs["lint"].Machine == nil {
+		t.Error("machine: true must be parsed")
+	}
+	when := c.Commands["test"].Steps[1]
+	if !when.IsConditional() || when.Condition != "<< parameters.race >>" || len(when.Steps) != 1 {
+		t.Errorf("Invalid when step. Actual: %+v", when)
+	}
+
+	jobs := c.Workflows["main"].Jobs
+	if len(c.Workflows) != 1 || len(jobs) != 4 {
+		t.Fatalf("Invalid workflows. Actual: %+v", c.Workflows)
+	}
+	if jobs[1].Job != "build" || jobs[1].Name != "build-<< matrix.os >>" || len(jobs[1].Matrix.Parameters["os"]) != 2 {
+		t.Errorf("Invalid workflow job. Actual: %+v", jobs[1])
+	}
+	if jobs[2].Type != config.JobTypeApproval || !reflect.DeepEqual(jobs[2].Filters.Branches.Only, config.Strings{"main"}) {
+		t.Errorf("Invalid workflow job. Actual: %+v", jobs[2])
+	}
+	if r := jobs[3].Requires[0]; r.Job != "hold" || !reflect.DeepEqual(r.Status, config.Strings{"success", "failed"}) {
+		t.Errorf("Invalid requirement. Actual: %+v", r)
+	}
+}
+
+func TestConfig_Marshal(t *testing.T) {
+	c, err := config.ParseFile("testdata/config.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip, err := config.Parse(out)
+	if err != nil {
+		t.Fatalf("Marshaled config must be parsed: %v\n%s", err, out)
+	}
+	again, err := roundTrip.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != string(again) {
+		t.Errorf("Config must round-trip.\nFirst:\n%s\nSecond:\n%s", out, again)
+	}
+}
+
+func TestConfig_Marshal_UnknownKeys(t *testing.T) {
+	c, err := config.Parse([]byte(`version: 2.1
+setup: true
+future_key: value
+executors:
+  go:
+    docker:
+      - image: cimg/go:1.16
+        future_image_key: 1
+    machine:
+      future_machine_key: true
+jobs:
+  build:
+    docker:
+      - image: cimg/go:1.16
+    circleci_ip_ranges: true
+    future_job_key:
+      nested: [a, b]
+    steps:
+      - run:
+          command: make
+          future_step_key: true
+workflows:
+  main:
+    future_workflow_key: 1
+    triggers:
+      - schedule:
+          cron: "0 0 * * *"
+          future_schedule_key: 1
+          filters:
+            branches:
+              only: main
+              future_branch_key: 1
+        future_trigger_key: 1
+    jobs:
+      - build
+      - test:
+          requires:
+            - build: [success, failed]
+              future_requirement_key: 1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Extra["future_key"] != "value" || c.Jobs["build"].Extra["future_job_key"] == nil {
+		t.Errorf("Unknown keys must be kept. Actual: %v, %v", c.Extra, c.Jobs["build"].Extra)
+	}
+	out, err := c.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"setup: true",
+		"future_key: value",
+		"future_image_key: 1",
+		"future_machine_key: true",
+		"circleci_ip_ranges: true",
+		"future_job_key:\n      nested:\n        - a\n        - b",
+		"future_step_key: true",
+		"future_workflow_key: 1",
+		"future_schedule_key: 1",
+		"future_branch_key: 1",
+		"future_trigger_key: 1",
+		"- build:\n                - success\n                - failed\n              future_requirement_key: 1",
+	} {
+		if !strings.Contains(string(out), s) {
+			t.Errorf("Marshaled config must contain %q.\n%s", s, out)
+		}
+	}
+	roundTrip, err := config.Parse(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, err := roundTrip.Marshal(); err != nil || string(again) != string(out) {
+		t.Errorf("Unknown keys must round-trip.\nFirst:\n%s\nSecond:\n%s", out, again)
+	}
+}
+
+func TestConfig_Marshal_Scalars(t *testing.T) {
+	c, err := config.Parse([]byte(`version: "2.1"
+jobs:
+  build:
+    docker:
+      - image: cimg/go:1.16
+    parallelism: 2
+    environment:
+      QUOTED_BOOL: "true"
+      QUOTED_INT: '1'
+      BOOL: true
+      INT: 1
+    steps:
+      - checkout
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := c.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`version: "2.1"`,
+		"parallelism: 2\n",
+		`QUOTED_BOOL: "true"`,
+		"QUOTED_INT: '1'",
+		"BOOL: true\n",
+		"INT: 1\n",
+	} {
+		if !strings.Contains(string(out), s) {
+			t.Errorf("Marshaled config must contain %q.\n%s", s, out)
+		}
+	}
+}
+
+func TestParse_Error(t *testing.T) {
+	_, err := config.Parse([]byte("version: 2.1\njobs:\n  build:\n    steps:\n      - run: a\n        checkout: b\n"))
+	e, ok := err.(*config.Error)
+	if !ok || e.Line != 5 {
+		t.Errorf("Expected an error at line 5. Actual: %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kind of Change.
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+)
+
+// Change represents a semantic difference between two configs.
+// Path locates the element, e.g. jobs.build.steps[2] or workflows.main.jobs.deploy.filters.
+// From and To are the elements in flow style YAML, empty for added and removed ones respectively.
+type Change struct {
+	Kind string `json:"kind"`
+	Path string `json:"path"`
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", c.Path, c.To)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", c.Path, c.From)
+	}
+	return fmt.Sprintf("~ %s: %s => %s", c.Path, c.From, c.To)
+}
+
+// Diff compares jobs and workflows of the configs, e.g. jobs added or removed, steps changed and filters differing.
+// Both configs are expected to be processed, e.g. a config processed by Process and the compiled config of a pipeline.
+func Diff(from, to *Config) []Change {
+	d := &differ{}
+	d.jobs(from.Jobs, to.Jobs)
+	d.workflows(from.Workflows, to.Workflows)
+	return d.changes
+}
+
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(kind, path string, from, to interface{}) {
+	c := Change{Kind: kind, Path: path}
+	if from != nil {
+		c.From = flow(from)
+	}
+	if to != nil {
+		c.To = flow(to)
+	}
+	d.changes = append(d.changes, c)
+}
+
+// compare reports the element modified if it differs.
+func (d *differ) compare(path string, from, to interface{}) {
+	f, t := flow(from), flow(to)
+	if f != t {
+		d.changes = append(d.changes, Change{Kind: ChangeModified, Path: path, From: f, To: t})
+	}
+}
+
+func (d *differ) jobs(from, to map[string]*Job) {
+	for _, name := range unionKeys(from, to) {
+		path := "jobs." + name
+		f, t := from[name], to[name]
+		switch {
+		case f == nil:
+			d.add(ChangeAdded, path, nil, t)
+		case t == nil:
+			d.add(ChangeRemoved, path, f, nil)
+		default:
+			d.compare(path+".executor", jobExecutor(f), jobExecutor(t))
+			d.compare(path+".environment", f.Environment, t.Environment)
+			d.compare(path+".parallelism", f.Parallelism, t.Parallelism)
+			d.steps(path+".steps", f.Steps, t.Steps)
+		}
+	}
+}
+
+// jobExecutor returns the executor of the job to compare. Executors are expected to be inlined.
+func jobExecutor(j *Job) *Executor {
+	return &Executor{
+		Docker:           j.Docker,
+		Machine:          j.Machine,
+		Macos:            j.Macos,
+		ResourceClass:    j.ResourceClass,
+		WorkingDirectory: j.WorkingDirectory,
+		Shell:            j.Shell,
+	}
+}
+
+// steps reports steps added, removed or modified, matching them by the longest common subsequence.
+// A step removed and another added at the same place are reported as modified.
+func (d *differ) steps(path string, from, to []*Step) {
+	fs, ts := make([]string, len(from)), make([]string, len(to))
+	for i, s := range from {
+		fs[i] = flow(normalizeStep(s))
+	}
+	for i, s := range to {
+		ts[i] = flow(normalizeStep(s))
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of fs[i:] and ts[j:].
+	lcs := make([][]int, len(fs)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(ts)+1)
+	}
+	for i := len(fs) - 1; i >= 0; i-- {
+		for j := len(ts) - 1; j >= 0; j-- {
+			if fs[i] == ts[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(fs) || j < len(ts) {
+		switch {
+		case i < len(fs) && j < len(ts) && fs[i] == ts[j]:
+			i++
+			j++
+		case i < len(fs) && j < len(ts) && lcs[i+1][j+1] == lcs[i][j]:
+			d.changes = append(d.changes, Change{Kind: ChangeModified, Path: fmt.Sprintf("%s[%d]", path, j), From: fs[i], To: ts[j]})
+			i++
+			j++
+		case j >= len(ts) || (i < len(fs) && lcs[i+1][j] >= lcs[i][j+1]):
+			d.changes = append(d.changes, Change{Kind: ChangeRemoved, Path: fmt.Sprintf("%s[%d]", path, i), From: fs[i]})
+			i++
+		default:
+			d.changes = append(d.changes, Change{Kind: ChangeAdded, Path: fmt.Sprintf("%s[%d]", path, j), To: ts[j]})
+			j++
+		}
+	}
+}
+
+// normalizeStep returns the step in the long form, e.g. run: make into run: {command: make}, as compiled configs have.
+func normalizeStep(s *Step) *Step {
+	if command, ok := s.Args.(string); ok && s.Name == "run" {
+		n := *s
+		n.Args = map[string]interface{}{"command": command}
+		return &n
+	}
+	return s
+}
+
+func (d *differ) workflows(from, to Workflows) {
+	for _, name := range unionKeys(from, to) {
+		path := "workflows." + name
+		f, t := from[name], to[name]
+		switch {
+		case f == nil:
+			d.add(ChangeAdded, path, nil, t)
+		case t == nil:
+			d.add(ChangeRemoved, path, f, nil)
+		default:
+			d.compare(path+".triggers", f.Triggers, t.Triggers)
+			d.workflowJobs(path+".jobs", f.Jobs, t.Jobs)
+		}
+	}
+}
+
+func (d *differ) workflowJobs(path string, from, to []*WorkflowJob) {
+	fm, tm := workflowJobsByName(from), workflowJobsByName(to)
+	for _, name := range unionKeys(fm, tm) {
+		p := path + "." + name
+		f, t := fm[name], tm[name]
+		switch {
+		case f == nil:
+			d.add(ChangeAdded, p, nil, t)
+		case t == nil:
+			d.add(ChangeRemoved, p, f, nil)
+		default:
+			d.compare(p+".type", f.Type, t.Type)
+			d.compare(p+".requires", requirementNames(f.Requires), requirementNames(t.Requires))
+			d.compare(p+".context", f.Context, t.Context)
+			d.compare(p+".filters", f.Filters, t.Filters)
+		}
+	}
+}
+
+func workflowJobsByName(jobs []*WorkflowJob) map[string]*WorkflowJob {
+	m := make(map[string]*WorkflowJob, len(jobs))
+	for _, j := range jobs {
+		name := j.Name
+		if name == "" {
+			name = j.Job
+		}
+		m[name] = j
+	}
+	return m
+}
+
+// requirementNames returns the requirements sorted, since their order does not matter.
+func requirementNames(rs []*Requirement) []string {
+	names := make([]string, 0, len(rs))
+	for _, r := range rs {
+		n := r.Job
+		if len(r.Status) != 0 {
+			n += ": " + strings.Join(r.Status, ",")
+		}
+		names = append(names, n)
+	}
+	return sortedKeys(stringSet(names))
+}
+
+func stringSet(ss []string) map[string]bool {
+	m := make(map[string]bool, len(ss))
+	for _, s := range ss {
+		m[s] = true
+	}
+	return m
+}
+
+// unionKeys returns the keys of both maps in order.
+func unionKeys(a, b interface{}) []string {
+	return sortedKeys(stringSet(append(sortedKeys(a), sortedKeys(b)...)))
+}
+
+// flow returns the value in flow style YAML in a line.
+func flow(v interface{}) string {
+	n := &yaml.Node{}
+	if err := n.Encode(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	setFlowStyle(n)
+	out, err := yaml.Marshal(n)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// setFlowStyle sets flow style to the node and its children. Quotes of scalars are reset,
+// so values of the same tag, e.g. "1" and '1', are written in the same way.
+func setFlowStyle(n *yaml.Node) {
+	if n.Kind == yaml.MappingNode || n.Kind == yaml.SequenceNode {
+		n.Style |= yaml.FlowStyle
+	}
+	if n.Kind == yaml.ScalarNode {
+		n.Style = 0
+	}
+	for _, c := range n.Content {
+		setFlowStyle(c)
+	}
+}
@@ -0,0 +1,89 @@
+package config_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ttyfky/go-circleci/config"
+)
+
+func TestDiff(t *testing.T) {
+	from, err := config.Parse([]byte(`version: 2
+jobs:
+  build:
+    docker:
+      - image: cimg/go:1.16
+    steps:
+      - checkout
+      - run: make
+      - run: make test
+    environment:
+      A: "1"
+      B: '2'
+  lint:
+    docker:
+      - image: cimg/go:1.16
+    steps:
+      - checkout
+workflows:
+  main:
+    jobs:
+      - lint
+      - build:
+          requires: [lint]
+          filters:
+            branches:
+              only: main
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	to, err := config.Parse([]byte(`version: 2
+jobs:
+  build:
+    docker:
+      - image: cimg/go:1.17
+    steps:
+      - checkout
+      - run:
+          command: make
+      - run: make vet
+      - run: make test
+    environment:
+      A: '1'
+      B: 2
+  deploy:
+    docker:
+      - image: cimg/go:1.17
+    steps:
+      - checkout
+workflows:
+  main:
+    jobs:
+      - build
+      - deploy:
+          requires: [build]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var actual []string
+	for _, c := range config.Diff(from, to) {
+		actual = append(actual, c.String())
+	}
+	expected := []string{
+		"~ jobs.build.executor: {docker: [{image: 'cimg/go:1.16'}]} => {docker: [{image: 'cimg/go:1.17'}]}",
+		"~ jobs.build.environment: {A: \"1\", B: \"2\"} => {A: \"1\", B: 2}",
+		"+ jobs.build.steps[2]: {run: {command: make vet}}",
+		"+ jobs.deploy: {docker: [{image: 'cimg/go:1.17'}], steps: [checkout]}",
+		"- jobs.lint: {docker: [{image: 'cimg/go:1.16'}], steps: [checkout]}",
+		"~ workflows.main.jobs.build.requires: [lint] => []",
+		"~ workflows.main.jobs.build.filters: {branches: {only: [main]}} => null",
+		"+ workflows.main.jobs.deploy: {deploy: {requires: [build]}}",
+		"- workflows.main.jobs.lint: lint",
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Invalid diff.\nExpected:\n%s\nActual:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}
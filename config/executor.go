@@ -0,0 +1,144 @@
+package config
+
+import "gopkg.in/yaml.v3"
+
+// Executor represents an executor defined in executors, also used for the one defined inline in a job.
+type Executor struct {
+	Pos              `yaml:"-"`
+	Description      string                 `yaml:"description,omitempty"`
+	Parameters       map[string]*Parameter  `yaml:"parameters,omitempty"`
+	Docker           []*DockerImage         `yaml:"docker,omitempty"`
+	Machine          *Machine               `yaml:"machine,omitempty"`
+	Macos            *Macos                 `yaml:"macos,omitempty"`
+	ResourceClass    Scalar                 `yaml:"resource_class,omitempty"`
+	WorkingDirectory string                 `yaml:"working_directory,omitempty"`
+	Shell            string                 `yaml:"shell,omitempty"`
+	Environment      map[string]Scalar      `yaml:"environment,omitempty"`
+	Extra            map[string]interface{} `yaml:",inline"`
+}
+
+// DockerImage represents an image of docker executor.
+type DockerImage struct {
+	Pos         `yaml:"-"`
+	Image       string                 `yaml:"image"`
+	Name        string                 `yaml:"name,omitempty"`
+	Entrypoint  Strings                `yaml:"entrypoint,omitempty"`
+	Command     Strings                `yaml:"command,omitempty"`
+	User        string                 `yaml:"user,omitempty"`
+	Environment map[string]Scalar      `yaml:"environment,omitempty"`
+	Auth        map[string]string      `yaml:"auth,omitempty"`
+	AwsAuth     map[string]string      `yaml:"aws_auth,omitempty"`
+	Extra       map[string]interface{} `yaml:",inline"`
+}
+
+// Machine represents machine executor. machine: true is parsed into Machine without image.
+type Machine struct {
+	Pos                `yaml:"-"`
+	Image              string                 `yaml:"image,omitempty"`
+	DockerLayerCaching Scalar                 `yaml:"docker_layer_caching,omitempty"`
+	Extra              map[string]interface{} `yaml:",inline"`
+}
+
+// Macos represents macos executor.
+type Macos struct {
+	Pos   `yaml:"-"`
+	Xcode Scalar                 `yaml:"xcode"`
+	Extra map[string]interface{} `yaml:",inline"`
+}
+
+// ExecutorRef represents a reference to an executor in a job, e.g. executor: node/default,
+// with parameters of the executor.
+type ExecutorRef struct {
+	Pos        `yaml:"-"`
+	Name       string
+	Parameters map[string]interface{}
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (e *Executor) UnmarshalYAML(n *yaml.Node) error {
+	type plain Executor
+	if err := n.Decode((*plain)(e)); err != nil {
+		return err
+	}
+	e.Pos = posOf(n)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *DockerImage) UnmarshalYAML(n *yaml.Node) error {
+	type plain DockerImage
+	if err := n.Decode((*plain)(d)); err != nil {
+		return err
+	}
+	d.Pos = posOf(n)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (m *Machine) UnmarshalYAML(n *yaml.Node) error {
+	m.Pos = posOf(n)
+	if n.Kind == yaml.ScalarNode {
+		var enabled bool
+		if err := n.Decode(&enabled); err != nil || !enabled {
+			return errorf(n, "machine must be true or a mapping")
+		}
+		return nil
+	}
+	type plain Machine
+	return n.Decode((*plain)(m))
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (m *Machine) MarshalYAML() (interface{}, error) {
+	if m.Image == "" && m.DockerLayerCaching.IsZero() && len(m.Extra) == 0 {
+		return true, nil
+	}
+	type plain Machine
+	return (*plain)(m), nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (m *Macos) UnmarshalYAML(n *yaml.Node) error {
+	type plain Macos
+	if err := n.Decode((*plain)(m)); err != nil {
+		return err
+	}
+	m.Pos = posOf(n)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (e *ExecutorRef) UnmarshalYAML(n *yaml.Node) error {
+	e.Pos = posOf(n)
+	if n.Kind == yaml.ScalarNode {
+		e.Name = n.Value
+		return nil
+	}
+	return mappingKeys(n, func(key string, k, v *yaml.Node) error {
+		if key == "name" {
+			e.Name = v.Value
+			return nil
+		}
+		var value interface{}
+		if err := v.Decode(&value); err != nil {
+			return err
+		}
+		if e.Parameters == nil {
+			e.Parameters = map[string]interface{}{}
+		}
+		e.Parameters[key] = value
+		return nil
+	})
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (e *ExecutorRef) MarshalYAML() (interface{}, error) {
+	if len(e.Parameters) == 0 {
+		return e.Name, nil
+	}
+	m := map[string]interface{}{"name": e.Name}
+	for k, v := range e.Parameters {
+		m[k] = v
+	}
+	return m, nil
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var expressionPattern = regexp.MustCompile(`<<\s*([A-Za-z0-9_.-]+)\s*>>`)
+
+// env holds the values of << >> expressions in a scope, e.g. parameters.name or pipeline.git.branch.
+type env struct {
+	values map[string]interface{}
+	// strict makes unknown parameters.* and pipeline.parameters.* an error. Other unknown values are kept as they are.
+	strict bool
+}
+
+func (e *env) with(values map[string]interface{}) *env {
+	merged := make(map[string]interface{}, len(e.values)+len(values))
+	for k, v := range e.values {
+		merged[k] = v
+	}
+	for k, v := range values {
+		merged[k] = v
+	}
+	return &env{values: merged, strict: e.strict}
+}
+
+func (e *env) lookup(n *yaml.Node, key string) (interface{}, bool, error) {
+	v, ok := e.values[key]
+	if !ok && e.strict && (strings.HasPrefix(key, "parameters.") || strings.HasPrefix(key, "pipeline.parameters.")) {
+		return nil, false, errorf(n, "%s is not defined", key)
+	}
+	return v, ok, nil
+}
+
+// substitute returns a copy of the node with the expressions replaced.
+// A scalar consisting of a single expression is replaced with the value as it is, e.g. a boolean or steps,
+// and a sequence given to an item of a sequence is spliced, e.g. steps given by a steps parameter.
+func (e *env) substitute(n *yaml.Node) (*yaml.Node, error) {
+	switch n.Kind {
+	case yaml.ScalarNode:
+		return e.substituteScalar(n)
+	case yaml.SequenceNode, yaml.MappingNode, yaml.DocumentNode:
+		c := *n
+		c.Content = nil
+		for i, child := range n.Content {
+			if n.Kind == yaml.MappingNode && i%2 == 0 {
+				c.Content = append(c.Content, child)
+				continue
+			}
+			s, err := e.substitute(child)
+			if err != nil {
+				return nil, err
+			}
+			if n.Kind == yaml.SequenceNode && child.Kind == yaml.ScalarNode && s.Kind == yaml.SequenceNode {
+				c.Content = append(c.Content, s.Content...)
+				continue
+			}
+			c.Content = append(c.Content, s)
+		}
+		return &c, nil
+	case yaml.AliasNode:
+		return e.substitute(n.Alias)
+	}
+	return n, nil
+}
+
+func (e *env) substituteScalar(n *yaml.Node) (*yaml.Node, error) {
+	matches := expressionPattern.FindAllStringSubmatchIndex(n.Value, -1)
+	if len(matches) == 0 {
+		return n, nil
+	}
+
+	if len(matches) == 1 && matches[0][0] == 0 && matches[0][1] == len(n.Value) {
+		v, ok, err := e.lookup(n, n.Value[matches[0][2]:matches[0][3]])
+		if err != nil || !ok {
+			return n, err
+		}
+		r := &yaml.Node{}
+		if err := r.Encode(v); err != nil {
+			return nil, err
+		}
+		r.Line, r.Column = n.Line, n.Column
+		return r, nil
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(n.Value[last:m[0]])
+		last = m[1]
+		v, ok, err := e.lookup(n, n.Value[m[2]:m[3]])
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			b.WriteString(n.Value[m[0]:m[1]])
+			continue
+		}
+		switch v.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, errorf(n, "%s can not be embedded in a string", n.Value[m[2]:m[3]])
+		}
+		fmt.Fprint(&b, v)
+	}
+	b.WriteString(n.Value[last:])
+	c := *n
+	c.Value = b.String()
+	c.Tag = "!!str"
+	return &c, nil
+}
+
+// substituteValue substitutes expressions in v, which is encoded into YAML, and decodes the result into out.
+func (e *env) substituteValue(v interface{}, out interface{}) error {
+	n := &yaml.Node{}
+	if err := n.Encode(v); err != nil {
+		return err
+	}
+	s, err := e.substitute(n)
+	if err != nil {
+		return err
+	}
+	return s.Decode(out)
+}
+
+// Evaluate evaluates a logic statement of when and unless, e.g. {and: [...]}, {equal: [a, b]} or a literal.
+// Literals are false if false, null, 0 or empty string, and true otherwise.
+func Evaluate(condition interface{}) (bool, error) {
+	m, ok := condition.(map[string]interface{})
+	if !ok {
+		return truthy(condition), nil
+	}
+	if len(m) != 1 {
+		return false, fmt.Errorf("logic statement must have exactly one key: %v", condition)
+	}
+	for op, arg := range m {
+		switch op {
+		case "and", "or":
+			args, ok := arg.([]interface{})
+			if !ok {
+				return false, fmt.Errorf("%s requires a list", op)
+			}
+			if len(args) == 0 {
+				return false, nil
+			}
+			for _, a := range args {
+				b, err := Evaluate(a)
+				if err != nil {
+					return false, err
+				}
+				if op == "and" && !b {
+					return false, nil
+				}
+				if op == "or" && b {
+					return true, nil
+				}
+			}
+			return op == "and", nil
+		case "not":
+			b, err := Evaluate(arg)
+			return !b, err
+		case "equal":
+			args, ok := arg.([]interface{})
+			if !ok {
+				return false, fmt.Errorf("equal requires a list")
+			}
+			if len(args) == 0 {
+				return false, nil
+			}
+			for _, a := range args[1:] {
+				if !valuesEqual(a, args[0]) {
+					return false, nil
+				}
+			}
+			return true, nil
+		case "matches":
+			am, ok := arg.(map[string]interface{})
+			if !ok {
+				return false, fmt.Errorf("matches requires pattern and value")
+			}
+			re, err := regexp.Compile("^(?:" + fmt.Sprint(am["pattern"]) + ")$")
+			if err != nil {
+				return false, err
+			}
+			return re.MatchString(fmt.Sprint(am["value"])), nil
+		default:
+			return false, fmt.Errorf("unknown logic statement %s", op)
+		}
+	}
+	return false, nil
+}
+
+// valuesEqual reports whether the values are equal in type and value, e.g. 1 does not equal "1".
+// Numbers of different kinds such as 1 and 1.0 are equal.
+func valuesEqual(a, b interface{}) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func truthy(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case int:
+		return t != 0
+	case float64:
+		return t != 0 && t == t
+	case string:
+		return t != ""
+	}
+	return true
+}
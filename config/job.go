@@ -0,0 +1,150 @@
+package config
+
+import "gopkg.in/yaml.v3"
+
+// Types of Parameter.
+const (
+	ParameterString     = "string"
+	ParameterBoolean    = "boolean"
+	ParameterInteger    = "integer"
+	ParameterEnum       = "enum"
+	ParameterExecutor   = "executor"
+	ParameterSteps      = "steps"
+	ParameterEnvVarName = "env_var_name"
+)
+
+// Step types of conditional steps.
+const (
+	StepWhen   = "when"
+	StepUnless = "unless"
+)
+
+// Parameter represents a parameter of a pipeline, a job, a command or an executor.
+// Default is nil if the parameter has no default.
+type Parameter struct {
+	Pos         `yaml:"-"`
+	Type        string                 `yaml:"type"`
+	Description string                 `yaml:"description,omitempty"`
+	Default     interface{}            `yaml:"default,omitempty"`
+	Enum        []string               `yaml:"enum,omitempty"`
+	Extra       map[string]interface{} `yaml:",inline"`
+}
+
+// Command represents a reusable command.
+type Command struct {
+	Pos         `yaml:"-"`
+	Description string                 `yaml:"description,omitempty"`
+	Parameters  map[string]*Parameter  `yaml:"parameters,omitempty"`
+	Steps       []*Step                `yaml:"steps"`
+	Extra       map[string]interface{} `yaml:",inline"`
+}
+
+// Job represents a job. The executor is given by Executor or defined inline by Docker, Machine or Macos.
+type Job struct {
+	Pos              `yaml:"-"`
+	Description      string                 `yaml:"description,omitempty"`
+	Parameters       map[string]*Parameter  `yaml:"parameters,omitempty"`
+	Executor         *ExecutorRef           `yaml:"executor,omitempty"`
+	Docker           []*DockerImage         `yaml:"docker,omitempty"`
+	Machine          *Machine               `yaml:"machine,omitempty"`
+	Macos            *Macos                 `yaml:"macos,omitempty"`
+	ResourceClass    Scalar                 `yaml:"resource_class,omitempty"`
+	WorkingDirectory string                 `yaml:"working_directory,omitempty"`
+	Shell            string                 `yaml:"shell,omitempty"`
+	Environment      map[string]Scalar      `yaml:"environment,omitempty"`
+	Parallelism      Scalar                 `yaml:"parallelism,omitempty"`
+	CircleCIIPRanges bool                   `yaml:"circleci_ip_ranges,omitempty"`
+	Steps            []*Step                `yaml:"steps"`
+	Extra            map[string]interface{} `yaml:",inline"`
+}
+
+// Step represents a step of a job or a command.
+//
+// Name is the type of the step such as run or checkout, or the name of a command, e.g. node/install.
+// Args is nil for a step without arguments, a string for the short form such as run: make,
+// and a map otherwise. Condition and Steps are set instead of Args for when and unless steps.
+type Step struct {
+	Pos       `yaml:"-"`
+	Name      string
+	Args      interface{}
+	Condition interface{}
+	Steps     []*Step
+}
+
+// IsConditional reports whether the step is a when or unless step.
+func (s *Step) IsConditional() bool {
+	return s.Name == StepWhen || s.Name == StepUnless
+}
+
+// conditionalStep represents arguments of when and unless steps.
+type conditionalStep struct {
+	Condition interface{} `yaml:"condition"`
+	Steps     []*Step     `yaml:"steps"`
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (p *Parameter) UnmarshalYAML(n *yaml.Node) error {
+	type plain Parameter
+	if err := n.Decode((*plain)(p)); err != nil {
+		return err
+	}
+	p.Pos = posOf(n)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (c *Command) UnmarshalYAML(n *yaml.Node) error {
+	type plain Command
+	if err := n.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	c.Pos = posOf(n)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (j *Job) UnmarshalYAML(n *yaml.Node) error {
+	type plain Job
+	if err := n.Decode((*plain)(j)); err != nil {
+		return err
+	}
+	j.Pos = posOf(n)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *Step) UnmarshalYAML(n *yaml.Node) error {
+	s.Pos = posOf(n)
+	switch n.Kind {
+	case yaml.ScalarNode:
+		s.Name = n.Value
+		return nil
+	case yaml.MappingNode:
+		if len(n.Content) != 2 {
+			return errorf(n, "step must have exactly one key")
+		}
+		s.Name = n.Content[0].Value
+		v := n.Content[1]
+		if s.IsConditional() {
+			var cs conditionalStep
+			if err := v.Decode(&cs); err != nil {
+				return err
+			}
+			s.Condition, s.Steps = cs.Condition, cs.Steps
+			return nil
+		}
+		return v.Decode(&s.Args)
+	}
+	return errorf(n, "step must be a string or a mapping")
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (s *Step) MarshalYAML() (interface{}, error) {
+	if s.IsConditional() {
+		return map[string]interface{}{s.Name: &conditionalStep{Condition: s.Condition, Steps: s.Steps}}, nil
+	}
+	if s.Args == nil {
+		return s.Name, nil
+	}
+	return map[string]interface{}{s.Name: s.Args}, nil
+}
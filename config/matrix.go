@@ -0,0 +1,76 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MatrixCombinations returns the combinations of the parameters of the matrix except the excluded ones.
+// Parameters are combined in the order of their names.
+func MatrixCombinations(m *Matrix) []map[string]interface{} {
+	combinations := []map[string]interface{}{{}}
+	for _, name := range sortedKeys(m.Parameters) {
+		var next []map[string]interface{}
+		for _, c := range combinations {
+			for _, value := range m.Parameters[name] {
+				n := make(map[string]interface{}, len(c)+1)
+				for k, v := range c {
+					n[k] = v
+				}
+				n[name] = value
+				next = append(next, n)
+			}
+		}
+		combinations = next
+	}
+
+	var result []map[string]interface{}
+	for _, c := range combinations {
+		if !isExcluded(m, c) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+func isExcluded(m *Matrix, combination map[string]interface{}) bool {
+	for _, ex := range m.Exclude {
+		match := len(ex) == len(combination)
+		for k, v := range ex {
+			if fmt.Sprint(combination[k]) != fmt.Sprint(v) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// MatrixJobName returns the name of the job expanded from the matrix job for the combination.
+// << matrix.name >> in the name of the workflow job is replaced with the value,
+// or the values are appended to the name of the job if the name is not given.
+func MatrixJobName(wj *WorkflowJob, combination map[string]interface{}) string {
+	if wj.Name != "" {
+		name := wj.Name
+		for k, v := range combination {
+			name = replaceExpression(name, "matrix."+k, fmt.Sprint(v))
+		}
+		return name
+	}
+	parts := []string{wj.Job}
+	for _, k := range sortedKeys(combination) {
+		parts = append(parts, fmt.Sprint(combination[k]))
+	}
+	return strings.Join(parts, "-")
+}
+
+// replaceExpression replaces << key >> in s with the value, allowing spaces around the key.
+func replaceExpression(s, key, value string) string {
+	for _, e := range []string{"<<" + key + ">>", "<< " + key + " >>", "<<" + key + " >>", "<< " + key + ">>"} {
+		s = strings.Replace(s, e, value, -1)
+	}
+	return s
+}
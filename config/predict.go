@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PredictedWorkflow represents whether a workflow runs for a pipeline.
+// Reason tells why it does not run. Names of workflows and jobs are the ones shown by the API.
+type PredictedWorkflow struct {
+	Name   string          `json:"name"`
+	Run    bool            `json:"run"`
+	Reason string          `json:"reason,omitempty"`
+	Jobs   []*PredictedJob `json:"jobs,omitempty"`
+}
+
+// PredictedJob represents whether a job in a workflow runs for a pipeline.
+// Name is the name of the job in the workflow, and Job is the name of its definition.
+// Approval is set for approval jobs, and jobs requiring them run only after the approval.
+type PredictedJob struct {
+	Name     string   `json:"name"`
+	Job      string   `json:"job"`
+	Run      bool     `json:"run"`
+	Reason   string   `json:"reason,omitempty"`
+	Approval bool     `json:"approval,omitempty"`
+	Requires []string `json:"requires,omitempty"`
+}
+
+// Predict tells which workflows and jobs run for a pipeline of the branch or tag with the parameters,
+// according to when and unless of workflows, filters of jobs and requires among them.
+// Workflows with triggers are scheduled ones and do not run for a push.
+func Predict(c *Config, opts *ProcessOptions) ([]*PredictedWorkflow, error) {
+	if opts == nil {
+		opts = &ProcessOptions{}
+	}
+	pipeline, err := pipelineEnv(c, opts)
+	if err != nil {
+		return nil, err
+	}
+	p := &processor{root: c, pipeline: pipeline}
+
+	var workflows []*PredictedWorkflow
+	for _, name := range sortedKeys(c.Workflows) {
+		w := c.Workflows[name]
+		pw := &PredictedWorkflow{Name: name}
+		workflows = append(workflows, pw)
+		if len(w.Triggers) != 0 {
+			pw.Reason = "workflow is scheduled"
+			continue
+		}
+		run, err := p.workflowEnabled(w)
+		if err != nil {
+			return nil, err
+		}
+		if !run {
+			pw.Reason = "when or unless condition of workflow is not satisfied"
+			continue
+		}
+		pw.Jobs = predictJobs(expandWorkflow(w), opts.Branch, opts.Tag)
+		for _, j := range pw.Jobs {
+			pw.Run = pw.Run || j.Run
+		}
+		if !pw.Run {
+			pw.Reason = "no job runs"
+		}
+	}
+	return workflows, nil
+}
+
+func predictJobs(invocations []*invocation, branch, tag string) []*PredictedJob {
+	jobs := make(map[string]*PredictedJob, len(invocations))
+	var ordered []*PredictedJob
+	for _, inv := range invocations {
+		j := &PredictedJob{Name: inv.name, Job: inv.wj.Job, Approval: inv.wj.Type == JobTypeApproval}
+		for _, r := range inv.requires {
+			j.Requires = append(j.Requires, r.Job)
+		}
+		j.Run, j.Reason = filtersAllow(inv.wj.Filters, branch, tag)
+		jobs[j.Name] = j
+		ordered = append(ordered, j)
+	}
+
+	// A job runs only if every job it requires runs.
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var visit func(j *PredictedJob)
+	visit = func(j *PredictedJob) {
+		if state[j.Name] == visited {
+			return
+		}
+		if state[j.Name] == visiting {
+			j.Run, j.Reason = false, "requires are cyclic"
+			return
+		}
+		state[j.Name] = visiting
+		for _, name := range j.Requires {
+			r, ok := jobs[name]
+			if !ok {
+				j.Run, j.Reason = false, fmt.Sprintf("required job %s does not exist", name)
+				break
+			}
+			visit(r)
+			if j.Run && !r.Run {
+				j.Run, j.Reason = false, fmt.Sprintf("required job %s does not run", name)
+			}
+		}
+		state[j.Name] = visited
+	}
+	for _, j := range ordered {
+		visit(j)
+	}
+	return ordered
+}
+
+// filtersAllow reports whether the filters allow the job to run for the branch or tag, and the reason if not.
+// Jobs run for a tag only if they have filters of tags.
+func filtersAllow(f *Filters, branch, tag string) (bool, string) {
+	if tag != "" {
+		if f == nil || f.Tags == nil {
+			return false, "jobs without filters.tags do not run for tags"
+		}
+		if !f.Tags.Match(tag) {
+			return false, fmt.Sprintf("tag %s does not match filters.tags", tag)
+		}
+		return true, ""
+	}
+	if f != nil && f.Branches != nil && !f.Branches.Match(branch) {
+		return false, fmt.Sprintf("branch %s does not match filters.branches", branch)
+	}
+	return true, ""
+}
+
+// Match reports whether the name matches any of Only, if given, and none of Ignore.
+// Items enclosed by slashes are regular expressions which must match the whole name.
+func (f *Filter) Match(name string) bool {
+	if len(f.Only) != 0 && !matchAnyFilter(f.Only, name) {
+		return false
+	}
+	return !matchAnyFilter(f.Ignore, name)
+}
+
+func matchAnyFilter(items []string, name string) bool {
+	for _, item := range items {
+		if len(item) >= 2 && strings.HasPrefix(item, "/") && strings.HasSuffix(item, "/") {
+			re, err := regexp.Compile("^(?:" + item[1:len(item)-1] + ")$")
+			if err == nil && re.MatchString(name) {
+				return true
+			}
+			continue
+		}
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
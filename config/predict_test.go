@@ -0,0 +1,123 @@
+package config_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ttyfky/go-circleci/config"
+)
+
+const predictConfig = `version: 2.1
+parameters:
+  nightly:
+    type: boolean
+    default: false
+jobs:
+  build:
+    parameters:
+      os:
+        type: string
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - checkout
+  deploy:
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - checkout
+workflows:
+  main:
+    unless: << pipeline.parameters.nightly >>
+    jobs:
+      - build:
+          matrix:
+            parameters:
+              os: [linux, darwin]
+          filters:
+            tags:
+              only: /^v.*/
+      - hold:
+          type: approval
+          requires: [build]
+          filters:
+            branches:
+              only: [main, /release\/.+/]
+            tags:
+              only: /^v.*/
+      - deploy:
+          requires: [hold]
+          filters:
+            branches:
+              ignore: /.*/
+            tags:
+              only: /^v.*/
+  schedule:
+    triggers:
+      - schedule:
+          cron: "0 0 * * *"
+    jobs:
+      - build:
+          os: linux
+`
+
+func TestPredict(t *testing.T) {
+	c, err := config.Parse([]byte(predictConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		opts     *config.ProcessOptions
+		expected string
+	}{
+		{
+			opts: &config.ProcessOptions{Branch: "feature"},
+			expected: `main: true
+  build-linux: true
+  build-darwin: true
+  hold: false branch feature does not match filters.branches
+  deploy: false branch feature does not match filters.branches
+schedule: false workflow is scheduled`,
+		},
+		{
+			opts: &config.ProcessOptions{Branch: "release/v1"},
+			expected: `main: true
+  build-linux: true
+  build-darwin: true
+  hold: true
+  deploy: false branch release/v1 does not match filters.branches
+schedule: false workflow is scheduled`,
+		},
+		{
+			opts: &config.ProcessOptions{Tag: "v1.0.0"},
+			expected: `main: true
+  build-linux: true
+  build-darwin: true
+  hold: true
+  deploy: true
+schedule: false workflow is scheduled`,
+		},
+		{
+			opts: &config.ProcessOptions{Branch: "main", Parameters: map[string]interface{}{"nightly": true}},
+			expected: `main: false when or unless condition of workflow is not satisfied
+schedule: false workflow is scheduled`,
+		},
+	}
+	for _, tt := range tests {
+		workflows, err := config.Predict(c, tt.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var lines []string
+		for _, w := range workflows {
+			lines = append(lines, strings.TrimSpace(fmt.Sprintf("%s: %t %s", w.Name, w.Run, w.Reason)))
+			for _, j := range w.Jobs {
+				lines = append(lines, strings.TrimRight(fmt.Sprintf("  %s: %t %s", j.Name, j.Run, j.Reason), " "))
+			}
+		}
+		if actual := strings.Join(lines, "\n"); actual != tt.expected {
+			t.Errorf("Invalid prediction for %+v.\nExpected:\n%s\nActual:\n%s", tt.opts, tt.expected, actual)
+		}
+	}
+}
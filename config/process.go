@@ -0,0 +1,441 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const maxExpansionDepth = 32
+
+// ProcessOptions represents the pipeline to process a config for.
+type ProcessOptions struct {
+	// Parameters are the pipeline parameters. Defaults of the config are used for the ones not given.
+	Parameters map[string]interface{}
+	// Branch and Tag are the values of pipeline.git.branch and pipeline.git.tag.
+	Branch string
+	Tag    string
+	// Values are other pipeline values, e.g. pipeline.git.revision. Expressions of unknown pipeline values are kept.
+	Values map[string]interface{}
+	// Orbs resolves orbs imported by reference. Only inline orbs can be processed if nil.
+	Orbs OrbResolver
+}
+
+// OrbResolver resolves an orb imported by reference, e.g. circleci/node@5.0.2, into its source.
+type OrbResolver interface {
+	OrbSource(ref string) ([]byte, error)
+}
+
+// Process compiles the config offline as CircleCI does for a pipeline.
+//
+// Parameters and << >> expressions are resolved, commands and executors are inlined, matrix jobs are expanded
+// into concrete jobs, and when and unless of workflows and steps are evaluated. Each job invoked by a workflow
+// becomes a job named as the workflow job. Orbs imported by reference are resolved by ProcessOptions.Orbs.
+func Process(c *Config, opts *ProcessOptions) (*Config, error) {
+	out, err := process(c, opts)
+	var e *Error
+	if errors.As(err, &e) && e.File == "" {
+		e.File = c.File
+	}
+	return out, err
+}
+
+func process(c *Config, opts *ProcessOptions) (*Config, error) {
+	if opts == nil {
+		opts = &ProcessOptions{}
+	}
+	pipeline, err := pipelineEnv(c, opts)
+	if err != nil {
+		return nil, err
+	}
+	p := &processor{root: c, pipeline: pipeline, orbs: opts.Orbs}
+
+	out := &Config{Version: Scalar{Value: "2"}, Jobs: map[string]*Job{}, Workflows: Workflows{}, File: c.File}
+	for _, name := range sortedKeys(c.Workflows) {
+		w := c.Workflows[name]
+		run, err := p.workflowEnabled(w)
+		if err != nil {
+			return nil, err
+		}
+		if !run {
+			continue
+		}
+		compiled, err := p.workflow(w, out.Jobs)
+		if err != nil {
+			return nil, err
+		}
+		out.Workflows[name] = compiled
+	}
+	return out, nil
+}
+
+// pipelineEnv returns the values of pipeline parameters and pipeline values.
+func pipelineEnv(c *Config, opts *ProcessOptions) (*env, error) {
+	values := map[string]interface{}{}
+	for k, v := range opts.Values {
+		values[k] = v
+	}
+	if opts.Branch != "" {
+		values["pipeline.git.branch"] = opts.Branch
+	}
+	if opts.Tag != "" {
+		values["pipeline.git.tag"] = opts.Tag
+	}
+	params, err := parameterValues(c.Pos, "pipeline", c.Parameters, opts.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range params {
+		values["pipeline."+k] = v
+	}
+	return &env{values: values, strict: true}, nil
+}
+
+// parameterValues returns the values of parameters keyed by parameters.name, with defaults for the ones not given.
+func parameterValues(pos Pos, target string, params map[string]*Parameter, args map[string]interface{}) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for name, v := range args {
+		p, ok := params[name]
+		if !ok {
+			return nil, &Error{Pos: pos, Message: fmt.Sprintf("%s has no parameter %s", target, name)}
+		}
+		if msg := checkParameterValue(p, v); msg != "" {
+			return nil, &Error{Pos: pos, Message: fmt.Sprintf("argument %s of %s %s", name, target, msg)}
+		}
+		values["parameters."+name] = v
+	}
+	for name, p := range params {
+		if _, ok := args[name]; ok {
+			continue
+		}
+		if p.Default == nil {
+			return nil, &Error{Pos: pos, Message: fmt.Sprintf("%s requires parameter %s", target, name)}
+		}
+		values["parameters."+name] = p.Default
+	}
+	return values, nil
+}
+
+type processor struct {
+	root     *Config
+	pipeline *env
+	orbs     OrbResolver
+	// resolved caches orbs resolved by reference.
+	resolved map[string]*Config
+}
+
+// resolve finds the scope of the name, i.e. the config or the inline orb defining it, and the name in the scope.
+func (p *processor) resolve(scope *Config, pos Pos, name string) (*Config, string, error) {
+	i := strings.Index(name, "/")
+	if i < 0 {
+		return scope, name, nil
+	}
+	orb, ok := scope.Orbs[name[:i]]
+	if !ok {
+		return nil, "", &Error{Pos: pos, Message: fmt.Sprintf("orb %s is not imported", name[:i])}
+	}
+	inline := orb.Inline
+	if inline == nil {
+		var err error
+		inline, err = p.resolveOrb(pos, orb.Ref)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	return p.resolve(inline, pos, name[i+1:])
+}
+
+// resolveOrb returns the orb imported by the reference.
+func (p *processor) resolveOrb(pos Pos, ref string) (*Config, error) {
+	if c, ok := p.resolved[ref]; ok {
+		return c, nil
+	}
+	if p.orbs == nil {
+		return nil, &Error{Pos: pos, Message: fmt.Sprintf("orb %s is not available offline", ref)}
+	}
+	source, err := p.orbs.OrbSource(ref)
+	if err != nil {
+		return nil, &Error{Pos: pos, Message: fmt.Sprintf("orb %s can not be resolved: %v", ref, err)}
+	}
+	c, err := Parse(source)
+	if err != nil {
+		return nil, &Error{Pos: pos, Message: fmt.Sprintf("orb %s can not be parsed: %v", ref, err)}
+	}
+	if p.resolved == nil {
+		p.resolved = map[string]*Config{}
+	}
+	p.resolved[ref] = c
+	return c, nil
+}
+
+func (p *processor) workflowEnabled(w *Workflow) (bool, error) {
+	if w.When != nil {
+		b, err := p.evaluate(w.Pos, w.When)
+		if err != nil || !b {
+			return false, err
+		}
+	}
+	if w.Unless != nil {
+		b, err := p.evaluate(w.Pos, w.Unless)
+		if err != nil || b {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// evaluate evaluates the logic statement with pipeline parameters and values.
+func (p *processor) evaluate(pos Pos, condition interface{}) (bool, error) {
+	var v interface{}
+	if err := p.pipeline.substituteValue(condition, &v); err != nil {
+		return false, err
+	}
+	b, err := Evaluate(v)
+	if err != nil {
+		return false, &Error{Pos: pos, Message: err.Error()}
+	}
+	return b, nil
+}
+
+// invocation represents a job invoked by a workflow, after matrix expansion.
+type invocation struct {
+	wj       *WorkflowJob
+	name     string
+	params   map[string]interface{}
+	requires []*Requirement
+}
+
+func (p *processor) workflow(w *Workflow, jobs map[string]*Job) (*Workflow, error) {
+	compiled := &Workflow{Pos: w.Pos, Triggers: w.Triggers}
+	names := map[string]bool{}
+	for _, inv := range expandWorkflow(w) {
+		if names[inv.name] {
+			return nil, &Error{Pos: inv.wj.Pos, Message: fmt.Sprintf("job %s is defined more than once", inv.name)}
+		}
+		names[inv.name] = true
+		if inv.wj.Type != JobTypeApproval {
+			j, err := p.job(inv)
+			if err != nil {
+				return nil, err
+			}
+			// Other workflows may use the same job, which must compile to the same one.
+			if prev, ok := jobs[inv.name]; ok && flow(prev) != flow(j) {
+				return nil, &Error{Pos: inv.wj.Pos, Message: fmt.Sprintf("job %s is compiled differently in another workflow", inv.name)}
+			}
+			jobs[inv.name] = j
+		}
+		compiled.Jobs = append(compiled.Jobs, &WorkflowJob{
+			Pos:      inv.wj.Pos,
+			Job:      inv.name,
+			Type:     inv.wj.Type,
+			Requires: inv.requires,
+			Context:  inv.wj.Context,
+			Filters:  inv.wj.Filters,
+		})
+	}
+	return compiled, nil
+}
+
+// expandWorkflow expands matrix jobs of the workflow, and requirements of their aliases into the expanded jobs.
+func expandWorkflow(w *Workflow) []*invocation {
+	var invocations []*invocation
+	aliases := map[string][]string{}
+	for _, wj := range w.Jobs {
+		for _, inv := range expandMatrix(wj) {
+			invocations = append(invocations, inv)
+			if wj.Matrix != nil {
+				alias := wj.Matrix.Alias
+				if alias == "" {
+					alias = wj.Job
+				}
+				aliases[alias] = append(aliases[alias], inv.name)
+			}
+		}
+	}
+
+	for _, inv := range invocations {
+		for _, r := range inv.wj.Requires {
+			names, ok := aliases[r.Job]
+			if !ok {
+				names = []string{r.Job}
+			}
+			for _, n := range names {
+				inv.requires = append(inv.requires, &Requirement{Pos: r.Pos, Job: n, Status: r.Status, Extra: r.Extra})
+			}
+		}
+	}
+	return invocations
+}
+
+// expandMatrix expands the workflow job into the jobs of its matrix, or returns the job itself without matrix.
+func expandMatrix(wj *WorkflowJob) []*invocation {
+	if wj.Matrix == nil {
+		name := wj.Name
+		if name == "" {
+			name = wj.Job
+		}
+		return []*invocation{{wj: wj, name: name, params: wj.Parameters}}
+	}
+	var invocations []*invocation
+	for _, combination := range MatrixCombinations(wj.Matrix) {
+		params := map[string]interface{}{}
+		for k, v := range wj.Parameters {
+			params[k] = v
+		}
+		for k, v := range combination {
+			params[k] = v
+		}
+		invocations = append(invocations, &invocation{wj: wj, name: MatrixJobName(wj, combination), params: params})
+	}
+	return invocations
+}
+
+// job compiles the job invoked by the workflow.
+func (p *processor) job(inv *invocation) (*Job, error) {
+	scope, name, err := p.resolve(p.root, inv.wj.Pos, inv.wj.Job)
+	if err != nil {
+		return nil, err
+	}
+	def, ok := scope.Jobs[name]
+	if !ok {
+		return nil, &Error{Pos: inv.wj.Pos, Message: fmt.Sprintf("job %s is not defined", inv.wj.Job)}
+	}
+	var args map[string]interface{}
+	if err := p.pipeline.substituteValue(inv.params, &args); err != nil {
+		return nil, err
+	}
+	values, err := parameterValues(inv.wj.Pos, "job "+inv.wj.Job, def.Parameters, args)
+	if err != nil {
+		return nil, err
+	}
+	e := p.pipeline.with(values)
+
+	steps := make([]*Step, 0, len(inv.wj.PreSteps)+len(def.Steps)+len(inv.wj.PostSteps))
+	steps = append(append(append(steps, inv.wj.PreSteps...), def.Steps...), inv.wj.PostSteps...)
+	withSteps := *def
+	withSteps.Steps = steps
+	withSteps.Parameters = nil
+
+	j := &Job{}
+	if err := e.substituteValue(&withSteps, j); err != nil {
+		return nil, err
+	}
+	j.Pos = def.Pos
+
+	if j.Executor != nil {
+		if err := p.inlineExecutor(scope, j); err != nil {
+			return nil, err
+		}
+	}
+	j.Steps, err = p.steps(scope, e, j.Steps, 0)
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// inlineExecutor copies the executor referred by the job into the job. Values of the job take precedence.
+func (p *processor) inlineExecutor(scope *Config, j *Job) error {
+	ref := j.Executor
+	s, name, err := p.resolve(scope, ref.Pos, ref.Name)
+	if err != nil {
+		return err
+	}
+	def, ok := s.Executors[name]
+	if !ok {
+		return &Error{Pos: ref.Pos, Message: fmt.Sprintf("executor %s is not defined", ref.Name)}
+	}
+	values, err := parameterValues(ref.Pos, "executor "+ref.Name, def.Parameters, ref.Parameters)
+	if err != nil {
+		return err
+	}
+	withoutParams := *def
+	withoutParams.Parameters = nil
+	e := &Executor{}
+	if err := p.pipeline.with(values).substituteValue(&withoutParams, e); err != nil {
+		return err
+	}
+
+	j.Executor = nil
+	j.Docker, j.Machine, j.Macos = e.Docker, e.Machine, e.Macos
+	if j.ResourceClass.IsZero() {
+		j.ResourceClass = e.ResourceClass
+	}
+	if j.WorkingDirectory == "" {
+		j.WorkingDirectory = e.WorkingDirectory
+	}
+	if j.Shell == "" {
+		j.Shell = e.Shell
+	}
+	if len(e.Environment) != 0 {
+		env := map[string]Scalar{}
+		for k, v := range e.Environment {
+			env[k] = v
+		}
+		for k, v := range j.Environment {
+			env[k] = v
+		}
+		j.Environment = env
+	}
+	return nil
+}
+
+// steps evaluates conditional steps and inlines commands. Expressions of the steps are already substituted.
+func (p *processor) steps(scope *Config, e *env, steps []*Step, depth int) ([]*Step, error) {
+	if depth > maxExpansionDepth {
+		return nil, fmt.Errorf("commands are nested too deep, more than %d", maxExpansionDepth)
+	}
+	var out []*Step
+	for _, s := range steps {
+		switch {
+		case s.IsConditional():
+			b, err := Evaluate(s.Condition)
+			if err != nil {
+				return nil, &Error{Pos: s.Pos, Message: err.Error()}
+			}
+			if b != (s.Name == StepWhen) {
+				continue
+			}
+			inner, err := p.steps(scope, e, s.Steps, depth)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, inner...)
+		case builtinSteps[s.Name]:
+			out = append(out, s)
+		default:
+			inlined, err := p.command(scope, s, depth)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, inlined...)
+		}
+	}
+	return out, nil
+}
+
+// command inlines the steps of the command invoked by the step.
+func (p *processor) command(scope *Config, s *Step, depth int) ([]*Step, error) {
+	cs, name, err := p.resolve(scope, s.Pos, s.Name)
+	if err != nil {
+		return nil, err
+	}
+	def, ok := cs.Commands[name]
+	if !ok {
+		return nil, &Error{Pos: s.Pos, Message: fmt.Sprintf("command %s is not defined", s.Name)}
+	}
+	args, ok := s.Args.(map[string]interface{})
+	if s.Args != nil && !ok {
+		return nil, &Error{Pos: s.Pos, Message: fmt.Sprintf("arguments of command %s must be a mapping", s.Name)}
+	}
+	values, err := parameterValues(s.Pos, "command "+s.Name, def.Parameters, args)
+	if err != nil {
+		return nil, err
+	}
+	e := p.pipeline.with(values)
+	var steps []*Step
+	if err := e.substituteValue(def.Steps, &steps); err != nil {
+		return nil, err
+	}
+	return p.steps(cs, e, steps, depth+1)
+}
@@ -0,0 +1,275 @@
+package config_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ttyfky/go-circleci/config"
+)
+
+const processConfig = `version: 2.1
+orbs:
+  tools:
+    commands:
+      greet:
+        parameters:
+          to:
+            type: string
+        steps:
+          - run: echo hello << parameters.to >>
+parameters:
+  deploy:
+    type: boolean
+    default: false
+executors:
+  go:
+    parameters:
+      version:
+        type: string
+        default: "1.15"
+    docker:
+      - image: cimg/go:<< parameters.version >>
+    environment:
+      GOFLAGS: -mod=mod
+commands:
+  test:
+    parameters:
+      race:
+        type: boolean
+        default: false
+      after:
+        type: steps
+        default: []
+    steps:
+      - run: go test ./...
+      - when:
+          condition: << parameters.race >>
+          steps:
+            - run: go test -race ./...
+      - << parameters.after >>
+jobs:
+  build:
+    parameters:
+      os:
+        type: string
+      version:
+        type: string
+        default: "1.16"
+    executor:
+      name: go
+      version: << parameters.version >>
+    environment:
+      GOOS: << parameters.os >>
+    steps:
+      - checkout
+      - test:
+          race: true
+          after:
+            - tools/greet:
+                to: << parameters.os >>
+  deploy:
+    executor: go
+    steps:
+      - run: ./deploy.sh << pipeline.git.branch >>
+workflows:
+  main:
+    jobs:
+      - build:
+          matrix:
+            parameters:
+              os: [linux, darwin]
+  release:
+    when:
+      and:
+        - << pipeline.parameters.deploy >>
+        - equal: [main, << pipeline.git.branch >>]
+    jobs:
+      - hold:
+          type: approval
+      - deploy:
+          requires: [hold]
+`
+
+func TestProcess(t *testing.T) {
+	c, err := config.Parse([]byte(processConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compiled, err := config.Process(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := compiled.Workflows["release"]; ok || len(compiled.Jobs) != 2 {
+		t.Fatalf("Only main workflow must be compiled. Actual: %+v", compiled.Workflows)
+	}
+	linux := compiled.Jobs["build-linux"]
+	if linux == nil || linux.Executor != nil || linux.Docker[0].Image != "cimg/go:1.16" {
+		t.Fatalf("Executor must be inlined. Actual: %+v", linux)
+	}
+	if linux.Environment["GOOS"].Value != "linux" || linux.Environment["GOFLAGS"].Value != "-mod=mod" {
+		t.Errorf("Invalid environment. Actual: %v", linux.Environment)
+	}
+	var steps []string
+	for _, s := range linux.Steps {
+		if s.Args == nil {
+			steps = append(steps, s.Name)
+			continue
+		}
+		steps = append(steps, s.Name+": "+s.Args.(string))
+	}
+	expected := "checkout,run: go test ./...,run: go test -race ./...,run: echo hello linux"
+	if strings.Join(steps, ",") != expected {
+		t.Errorf("Invalid steps.\nExpected: %s\nActual:   %s", expected, strings.Join(steps, ","))
+	}
+
+	compiled, err = config.Process(c, &config.ProcessOptions{
+		Parameters: map[string]interface{}{"deploy": true},
+		Branch:     "main",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := compiled.Workflows["release"]
+	if release == nil || len(release.Jobs) != 2 || release.Jobs[1].Requires[0].Job != "hold" {
+		t.Fatalf("Invalid release workflow. Actual: %+v", release)
+	}
+	if args := compiled.Jobs["deploy"].Steps[0].Args; args != "./deploy.sh main" {
+		t.Errorf("Pipeline values must be substituted. Actual: %v", args)
+	}
+	if _, err := compiled.Marshal(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestProcess_Error(t *testing.T) {
+	c, err := config.Parse([]byte(processConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = config.Process(c, &config.ProcessOptions{Parameters: map[string]interface{}{"deploy": "yes"}})
+	if err == nil || !strings.Contains(err.Error(), "argument deploy of pipeline must be boolean") {
+		t.Errorf("Expected an error of pipeline parameter. Actual: %v", err)
+	}
+}
+
+func TestProcess_SharedJob(t *testing.T) {
+	c, err := config.Parse([]byte(`version: 2.1
+jobs:
+  build:
+    parameters:
+      target:
+        type: string
+        default: all
+    docker:
+      - image: cimg/base:stable
+    steps:
+      - run: make << parameters.target >>
+workflows:
+  main:
+    jobs:
+      - build
+  nightly:
+    jobs:
+      - build
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compiled, err := config.Process(c, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compiled.Jobs) != 1 || len(compiled.Workflows["main"].Jobs) != 1 || len(compiled.Workflows["nightly"].Jobs) != 1 {
+		t.Errorf("Both workflows must use the build job. Actual: %+v", compiled.Workflows)
+	}
+
+	c.Workflows["nightly"].Jobs[0].Parameters = map[string]interface{}{"target": "nightly"}
+	if _, err := config.Process(c, nil); err == nil || !strings.Contains(err.Error(), "compiled differently") {
+		t.Errorf("Expected an error of the job compiled differently. Actual: %v", err)
+	}
+}
+
+type orbSources map[string]string
+
+func (o orbSources) OrbSource(ref string) ([]byte, error) {
+	s, ok := o[ref]
+	if !ok {
+		return nil, fmt.Errorf("%s is not found", ref)
+	}
+	return []byte(s), nil
+}
+
+func TestProcess_Orbs(t *testing.T) {
+	c, err := config.Parse([]byte(`version: 2.1
+orbs:
+  go: circleci/go@1.7.0
+jobs:
+  build:
+    executor: go/default
+    steps:
+      - go/test
+workflows:
+  main:
+    jobs:
+      - build
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.Process(c, nil); err == nil || !strings.Contains(err.Error(), "not available offline") {
+		t.Errorf("Expected an error of orb. Actual: %v", err)
+	}
+
+	orbs := orbSources{"circleci/go@1.7.0": `version: 2.1
+executors:
+  default:
+    docker:
+      - image: cimg/go:1.17
+commands:
+  test:
+    steps:
+      - run: go test ./...
+`}
+	compiled, err := config.Process(c, &config.ProcessOptions{Orbs: orbs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	build := compiled.Jobs["build"]
+	if build.Docker[0].Image != "cimg/go:1.17" || build.Steps[0].Args != "go test ./..." {
+		t.Errorf("Orb must be resolved. Actual: %+v", build)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		condition interface{}
+		expected  bool
+	}{
+		{true, true},
+		{"", false},
+		{0, false},
+		{map[string]interface{}{"not": false}, true},
+		{map[string]interface{}{"or": []interface{}{false, "x"}}, true},
+		{map[string]interface{}{"and": []interface{}{true, nil}}, false},
+		{map[string]interface{}{"equal": []interface{}{1, 1, 1}}, true},
+		{map[string]interface{}{"equal": []interface{}{}}, false},
+		{map[string]interface{}{"equal": []interface{}{1, "1"}}, false},
+		{map[string]interface{}{"equal": []interface{}{true, "true"}}, false},
+		{map[string]interface{}{"equal": []interface{}{1, 1.0}}, true},
+		{map[string]interface{}{"equal": []interface{}{"main", "main"}}, true},
+		{map[string]interface{}{"matches": map[string]interface{}{"pattern": "release/.+", "value": "release/v1"}}, true},
+		{map[string]interface{}{"matches": map[string]interface{}{"pattern": "release", "value": "pre-release"}}, false},
+	}
+	for _, tt := range tests {
+		actual, err := config.Evaluate(tt.condition)
+		if err != nil {
+			t.Errorf("Unexpected error for %v: %v", tt.condition, err)
+			continue
+		}
+		if actual != tt.expected {
+			t.Errorf("Invalid result of %v. Expected: %t, Actual: %t", tt.condition, tt.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,395 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity of Diagnostic.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// builtinSteps are the steps CircleCI provides.
+var builtinSteps = map[string]bool{
+	"run":                  true,
+	"checkout":             true,
+	"setup_remote_docker":  true,
+	"save_cache":           true,
+	"restore_cache":        true,
+	"store_artifacts":      true,
+	"store_test_results":   true,
+	"persist_to_workspace": true,
+	"attach_workspace":     true,
+	"add_ssh_keys":         true,
+	"deploy":               true,
+	StepWhen:               true,
+	StepUnless:             true,
+}
+
+var parameterTypes = map[string]bool{
+	ParameterString:     true,
+	ParameterBoolean:    true,
+	ParameterInteger:    true,
+	ParameterEnum:       true,
+	ParameterExecutor:   true,
+	ParameterSteps:      true,
+	ParameterEnvVarName: true,
+}
+
+// Diagnostic represents a problem found in a config.
+type Diagnostic struct {
+	File string `json:"file,omitempty"`
+	Pos
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	s := fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Column, d.Severity, d.Message)
+	if d.File != "" {
+		s = d.File + ":" + s
+	}
+	return s
+}
+
+// HasErrors reports whether any of the diagnostics is an error.
+func HasErrors(ds []Diagnostic) bool {
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the config offline and returns diagnostics sorted by position.
+// It finds references to undefined jobs, executors, commands and parameters, wrong types of parameters,
+// duplicate job names and cyclic requires in workflows. Jobs, commands and executors of orbs are not checked
+// since their definitions are not available offline.
+func Validate(c *Config) []Diagnostic {
+	v := &validator{config: c}
+	v.validate()
+	sort.SliceStable(v.diagnostics, func(i, j int) bool {
+		a, b := v.diagnostics[i].Pos, v.diagnostics[j].Pos
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return v.diagnostics
+}
+
+type validator struct {
+	config      *Config
+	diagnostics []Diagnostic
+}
+
+func (v *validator) report(pos Pos, severity, format string, a ...interface{}) {
+	v.diagnostics = append(v.diagnostics, Diagnostic{
+		File:     v.config.File,
+		Pos:      pos,
+		Severity: severity,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+func (v *validator) validate() {
+	c := v.config
+	if c.Version.Value != "2.1" {
+		v.report(c.Pos, SeverityError, "version must be 2.1, got %q", c.Version.Value)
+	}
+	v.parameters(c.Parameters)
+	for _, name := range sortedKeys(c.Executors) {
+		v.parameters(c.Executors[name].Parameters)
+	}
+	for _, name := range sortedKeys(c.Commands) {
+		cmd := c.Commands[name]
+		v.parameters(cmd.Parameters)
+		v.steps(cmd.Steps)
+	}
+	for _, name := range sortedKeys(c.Jobs) {
+		v.job(name, c.Jobs[name])
+	}
+
+	used := map[string]bool{}
+	for _, name := range sortedKeys(c.Workflows) {
+		v.workflow(name, c.Workflows[name], used)
+	}
+	for _, name := range sortedKeys(c.Jobs) {
+		if !used[name] {
+			v.report(c.Jobs[name].Pos, SeverityWarning, "job %s is not used in any workflow", name)
+		}
+	}
+}
+
+// parameters checks definitions of parameters.
+func (v *validator) parameters(params map[string]*Parameter) {
+	for _, name := range sortedKeys(params) {
+		p := params[name]
+		if !parameterTypes[p.Type] {
+			v.report(p.Pos, SeverityError, "parameter %s has unknown type %q", name, p.Type)
+			continue
+		}
+		if p.Type == ParameterEnum && len(p.Enum) == 0 {
+			v.report(p.Pos, SeverityError, "enum parameter %s must have enum values", name)
+		}
+		if p.Default != nil {
+			if msg := checkParameterValue(p, p.Default); msg != "" {
+				v.report(p.Pos, SeverityError, "default of parameter %s %s", name, msg)
+			}
+		}
+	}
+}
+
+// arguments checks arguments given to parameters.
+func (v *validator) arguments(pos Pos, target string, params map[string]*Parameter, args map[string]interface{}, ignore ...string) {
+	for _, name := range sortedKeys(args) {
+		if containsString(ignore, name) {
+			continue
+		}
+		p, ok := params[name]
+		if !ok {
+			v.report(pos, SeverityError, "%s has no parameter %s", target, name)
+			continue
+		}
+		if msg := checkParameterValue(p, args[name]); msg != "" {
+			v.report(pos, SeverityError, "argument %s of %s %s", name, target, msg)
+		}
+	}
+	for _, name := range sortedKeys(params) {
+		if _, ok := args[name]; !ok && params[name].Default == nil && !containsString(ignore, name) {
+			v.report(pos, SeverityError, "%s requires parameter %s", target, name)
+		}
+	}
+}
+
+// checkParameterValue returns what is wrong with the value of the parameter, or empty string.
+func checkParameterValue(p *Parameter, value interface{}) string {
+	if s, ok := value.(string); ok && isExpression(s) {
+		return ""
+	}
+	switch p.Type {
+	case ParameterBoolean:
+		if _, ok := value.(bool); !ok {
+			return fmt.Sprintf("must be boolean, got %v", value)
+		}
+	case ParameterInteger:
+		if _, ok := value.(int); !ok {
+			return fmt.Sprintf("must be integer, got %v", value)
+		}
+	case ParameterString, ParameterEnvVarName:
+		if _, ok := value.(string); !ok {
+			return fmt.Sprintf("must be string, got %v", value)
+		}
+	case ParameterEnum:
+		s, ok := value.(string)
+		if !ok || !containsString(p.Enum, s) {
+			return fmt.Sprintf("must be one of %s, got %v", strings.Join(p.Enum, ", "), value)
+		}
+	}
+	return ""
+}
+
+func (v *validator) job(name string, j *Job) {
+	v.parameters(j.Parameters)
+	inline := len(j.Docker) != 0 || j.Machine != nil || j.Macos != nil
+	switch {
+	case j.Executor != nil && inline:
+		v.report(j.Pos, SeverityError, "job %s must not have both executor and docker, machine or macos", name)
+	case j.Executor != nil:
+		v.executorRef(j.Executor)
+	case !inline:
+		v.report(j.Pos, SeverityError, "job %s has no executor", name)
+	}
+	if len(j.Steps) == 0 {
+		v.report(j.Pos, SeverityError, "job %s has no steps", name)
+	}
+	v.steps(j.Steps)
+}
+
+func (v *validator) executorRef(ref *ExecutorRef) {
+	if isExpression(ref.Name) {
+		return
+	}
+	if v.isOrbRef(ref.Name) {
+		return
+	}
+	e, ok := v.config.Executors[ref.Name]
+	if !ok {
+		v.report(ref.Pos, SeverityError, "executor %s is not defined", ref.Name)
+		return
+	}
+	v.arguments(ref.Pos, "executor "+ref.Name, e.Parameters, ref.Parameters)
+}
+
+func (v *validator) steps(steps []*Step) {
+	for _, s := range steps {
+		switch {
+		case isExpression(s.Name):
+		case s.IsConditional():
+			v.steps(s.Steps)
+		case builtinSteps[s.Name]:
+		case v.isOrbRef(s.Name):
+		default:
+			cmd, ok := v.config.Commands[s.Name]
+			if !ok {
+				v.report(s.Pos, SeverityError, "command %s is not defined", s.Name)
+				continue
+			}
+			args, ok := s.Args.(map[string]interface{})
+			if s.Args != nil && !ok {
+				v.report(s.Pos, SeverityError, "arguments of command %s must be a mapping", s.Name)
+				continue
+			}
+			v.arguments(s.Pos, "command "+s.Name, cmd.Parameters, args)
+		}
+	}
+}
+
+// isOrbRef reports whether the name refers to an element of an imported orb, e.g. node/install.
+func (v *validator) isOrbRef(name string) bool {
+	i := strings.Index(name, "/")
+	if i < 0 {
+		return false
+	}
+	_, ok := v.config.Orbs[name[:i]]
+	return ok
+}
+
+func (v *validator) workflow(name string, w *Workflow, used map[string]bool) {
+	names := map[string]*WorkflowJob{}
+	for _, wj := range w.Jobs {
+		used[wj.Job] = true
+		v.workflowJob(wj)
+		for _, n := range workflowJobNames(wj) {
+			if prev, ok := names[n]; ok {
+				v.report(wj.Pos, SeverityError, "job name %s in workflow %s is already used at line %d", n, name, prev.Line)
+				continue
+			}
+			names[n] = wj
+		}
+	}
+
+	requires := map[string][]string{}
+	for _, wj := range w.Jobs {
+		var deps []string
+		for _, r := range wj.Requires {
+			if _, ok := names[r.Job]; !ok {
+				v.report(r.Pos, SeverityError, "job %s required in workflow %s does not exist", r.Job, name)
+				continue
+			}
+			deps = append(deps, r.Job)
+		}
+		for _, n := range workflowJobNames(wj) {
+			requires[n] = append(requires[n], deps...)
+		}
+	}
+	if cycle := findCycle(requires); cycle != nil {
+		v.report(names[cycle[0]].Pos, SeverityError, "workflow %s has cyclic requires: %s", name, strings.Join(cycle, " -> "))
+	}
+}
+
+func (v *validator) workflowJob(wj *WorkflowJob) {
+	if wj.Type == JobTypeApproval {
+		return
+	}
+	if v.isOrbRef(wj.Job) {
+		return
+	}
+	j, ok := v.config.Jobs[wj.Job]
+	if !ok {
+		v.report(wj.Pos, SeverityError, "job %s is not defined", wj.Job)
+		return
+	}
+	var matrixParams []string
+	if wj.Matrix != nil {
+		for _, p := range sortedKeys(wj.Matrix.Parameters) {
+			if _, ok := j.Parameters[p]; !ok {
+				v.report(wj.Matrix.Pos, SeverityError, "job %s has no parameter %s given in matrix", wj.Job, p)
+			}
+			matrixParams = append(matrixParams, p)
+		}
+	}
+	v.arguments(wj.Pos, "job "+wj.Job, j.Parameters, wj.Parameters, matrixParams...)
+	v.steps(wj.PreSteps)
+	v.steps(wj.PostSteps)
+}
+
+// workflowJobNames returns the names the workflow job can be required by.
+// A matrix job can be required by its alias and the names of the expanded jobs.
+func workflowJobNames(wj *WorkflowJob) []string {
+	name := wj.Name
+	if name == "" {
+		name = wj.Job
+	}
+	if wj.Matrix == nil {
+		return []string{name}
+	}
+	alias := wj.Matrix.Alias
+	if alias == "" {
+		alias = wj.Job
+	}
+	names := []string{alias}
+	for _, combination := range MatrixCombinations(wj.Matrix) {
+		n := MatrixJobName(wj, combination)
+		if n != alias {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// findCycle returns a cycle in the graph of requires, or nil if none.
+func findCycle(requires map[string][]string) []string {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := map[string]int{}
+	var stack []string
+	var visit func(n string) []string
+	visit = func(n string) []string {
+		switch state[n] {
+		case visiting:
+			for i, s := range stack {
+				if s == n {
+					return append(append([]string{}, stack[i:]...), n)
+				}
+			}
+		case visited:
+			return nil
+		}
+		state[n] = visiting
+		stack = append(stack, n)
+		for _, d := range requires[n] {
+			if cycle := visit(d); cycle != nil {
+				return cycle
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[n] = visited
+		return nil
+	}
+	for _, n := range sortedKeys(requires) {
+		if cycle := visit(n); cycle != nil {
+			return cycle
+		}
+	}
+	return nil
+}
+
+func isExpression(s string) bool {
+	return strings.Contains(s, "<<")
+}
+
+func containsString(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package config_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ttyfky/go-circleci/config"
+)
+
+const invalidConfig = `version: 2.1
+orbs:
+  node: circleci/node@5.0.2
+executors:
+  go:
+    docker:
+      - image: cimg/go:1.16
+commands:
+  greet:
+    parameters:
+      to:
+        type: string
+    steps:
+      - run: echo << parameters.to >>
+jobs:
+  build:
+    executor: golang
+    steps:
+      - checkout
+      - greet
+      - greeting
+      - node/install
+  test:
+    executor: go
+    parameters:
+      count:
+        type: integer
+        default: one
+    steps:
+      - greet:
+          to: world
+  lint:
+    executor: go
+    steps:
+      - checkout
+workflows:
+  main:
+    jobs:
+      - build:
+          requires: [test]
+      - test:
+          requires: [build]
+      - test
+      - deploy
+      - hold:
+          type: approval
+          requires: [missing]
+`
+
+func TestValidate(t *testing.T) {
+	c, err := config.Parse([]byte(invalidConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	diagnostics := config.Validate(c)
+	expected := []string{
+		"17:15: error: executor golang is not defined",
+		"20:9: error: command greet requires parameter to",
+		"21:9: error: command greeting is not defined",
+		"27:9: error: default of parameter count must be integer, got one",
+		"33:5: warning: job lint is not used in any workflow",
+		"39:9: error: workflow main has cyclic requires: build -> test -> build",
+		"43:9: error: job name test in workflow main is already used at line 41",
+		"44:9: error: job deploy is not defined",
+		"47:22: error: job missing required in workflow main does not exist",
+	}
+	var actual []string
+	for _, d := range diagnostics {
+		actual = append(actual, d.String())
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Invalid diagnostics.\nExpected:\n%s\nActual:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+	if !config.HasErrors(diagnostics) {
+		t.Error("Expected errors")
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	c, err := config.ParseFile("testdata/config.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diagnostics := config.Validate(c); len(diagnostics) != 0 {
+		t.Errorf("Expected no diagnostics. Actual: %v", diagnostics)
+	}
+}
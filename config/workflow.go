@@ -0,0 +1,272 @@
+package config
+
+import "gopkg.in/yaml.v3"
+
+// JobTypeApproval is the type of a workflow job which waits for an approval.
+const JobTypeApproval = "approval"
+
+// Workflows represents workflows of a config. version key of config 2.0 is ignored.
+type Workflows map[string]*Workflow
+
+// Workflow represents a workflow.
+type Workflow struct {
+	Pos      `yaml:"-"`
+	When     interface{}            `yaml:"when,omitempty"`
+	Unless   interface{}            `yaml:"unless,omitempty"`
+	Triggers []*Trigger             `yaml:"triggers,omitempty"`
+	Jobs     []*WorkflowJob         `yaml:"jobs"`
+	Extra    map[string]interface{} `yaml:",inline"`
+}
+
+// Trigger represents a scheduled trigger of a workflow.
+type Trigger struct {
+	Schedule *Schedule              `yaml:"schedule"`
+	Extra    map[string]interface{} `yaml:",inline"`
+}
+
+// Schedule represents a schedule of a workflow.
+type Schedule struct {
+	Cron    string                 `yaml:"cron"`
+	Filters *Filters               `yaml:"filters,omitempty"`
+	Extra   map[string]interface{} `yaml:",inline"`
+}
+
+// WorkflowJob represents a job in a workflow.
+//
+// Job is the name of the job definition, and Name overrides the name of the job in the workflow.
+// Parameters holds the arguments to parameters of the job.
+type WorkflowJob struct {
+	Pos        `yaml:"-"`
+	Job        string
+	Name       string
+	Type       string
+	Requires   []*Requirement
+	Context    Strings
+	Filters    *Filters
+	Matrix     *Matrix
+	PreSteps   []*Step
+	PostSteps  []*Step
+	Parameters map[string]interface{}
+}
+
+// Requirement represents a job required by a workflow job.
+// Status is the statuses of the required job to run on, success only if empty.
+// Extra holds keys following the job name in the mapping, which are written back after it.
+type Requirement struct {
+	Pos    `yaml:"-"`
+	Job    string
+	Status Strings
+	Extra  map[string]interface{}
+}
+
+// Filters represents filters of a workflow job or a schedule.
+type Filters struct {
+	Pos      `yaml:"-"`
+	Branches *Filter                `yaml:"branches,omitempty"`
+	Tags     *Filter                `yaml:"tags,omitempty"`
+	Extra    map[string]interface{} `yaml:",inline"`
+}
+
+// Filter represents a filter of branches or tags. Each item is a name or a regular expression enclosed by slashes.
+type Filter struct {
+	Only   Strings                `yaml:"only,omitempty"`
+	Ignore Strings                `yaml:"ignore,omitempty"`
+	Extra  map[string]interface{} `yaml:",inline"`
+}
+
+// Matrix represents a matrix of a workflow job.
+type Matrix struct {
+	Pos        `yaml:"-"`
+	Alias      string                   `yaml:"alias,omitempty"`
+	Parameters map[string][]interface{} `yaml:"parameters"`
+	Exclude    []map[string]interface{} `yaml:"exclude,omitempty"`
+	Extra      map[string]interface{}   `yaml:",inline"`
+}
+
+// workflowJob represents the keys of WorkflowJob which are not parameters of the job.
+type workflowJob struct {
+	Name      string         `yaml:"name,omitempty"`
+	Type      string         `yaml:"type,omitempty"`
+	Requires  []*Requirement `yaml:"requires,omitempty"`
+	Context   Strings        `yaml:"context,omitempty"`
+	Filters   *Filters       `yaml:"filters,omitempty"`
+	Matrix    *Matrix        `yaml:"matrix,omitempty"`
+	PreSteps  []*Step        `yaml:"pre-steps,omitempty"`
+	PostSteps []*Step        `yaml:"post-steps,omitempty"`
+}
+
+var workflowJobKeys = map[string]bool{
+	"name": true, "type": true, "requires": true, "context": true,
+	"filters": true, "matrix": true, "pre-steps": true, "post-steps": true,
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (ws *Workflows) UnmarshalYAML(n *yaml.Node) error {
+	*ws = Workflows{}
+	return mappingKeys(n, func(key string, k, v *yaml.Node) error {
+		if key == "version" {
+			return nil
+		}
+		w := &Workflow{}
+		if err := v.Decode(w); err != nil {
+			return err
+		}
+		(*ws)[key] = w
+		return nil
+	})
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (w *Workflow) UnmarshalYAML(n *yaml.Node) error {
+	type plain Workflow
+	if err := n.Decode((*plain)(w)); err != nil {
+		return err
+	}
+	w.Pos = posOf(n)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (j *WorkflowJob) UnmarshalYAML(n *yaml.Node) error {
+	j.Pos = posOf(n)
+	if n.Kind == yaml.ScalarNode {
+		j.Job = n.Value
+		return nil
+	}
+	if n.Kind != yaml.MappingNode || len(n.Content) != 2 {
+		return errorf(n, "workflow job must be a name or a mapping with exactly one key")
+	}
+	j.Job = n.Content[0].Value
+	v := n.Content[1]
+	if v.Kind == yaml.ScalarNode && v.Tag == "!!null" {
+		return nil
+	}
+
+	var wj workflowJob
+	if err := v.Decode(&wj); err != nil {
+		return err
+	}
+	j.Name, j.Type, j.Requires, j.Context = wj.Name, wj.Type, wj.Requires, wj.Context
+	j.Filters, j.Matrix, j.PreSteps, j.PostSteps = wj.Filters, wj.Matrix, wj.PreSteps, wj.PostSteps
+	return mappingKeys(v, func(key string, k, v *yaml.Node) error {
+		if workflowJobKeys[key] {
+			return nil
+		}
+		var value interface{}
+		if err := v.Decode(&value); err != nil {
+			return err
+		}
+		if j.Parameters == nil {
+			j.Parameters = map[string]interface{}{}
+		}
+		j.Parameters[key] = value
+		return nil
+	})
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (j *WorkflowJob) MarshalYAML() (interface{}, error) {
+	wj := &workflowJob{
+		Name:      j.Name,
+		Type:      j.Type,
+		Requires:  j.Requires,
+		Context:   j.Context,
+		Filters:   j.Filters,
+		Matrix:    j.Matrix,
+		PreSteps:  j.PreSteps,
+		PostSteps: j.PostSteps,
+	}
+	n := &yaml.Node{}
+	if err := n.Encode(wj); err != nil {
+		return nil, err
+	}
+	if len(j.Parameters) != 0 {
+		params := &yaml.Node{}
+		if err := params.Encode(j.Parameters); err != nil {
+			return nil, err
+		}
+		n.Content = append(n.Content, params.Content...)
+	}
+	if len(n.Content) == 0 {
+		return j.Job, nil
+	}
+	return map[string]*yaml.Node{j.Job: n}, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (r *Requirement) UnmarshalYAML(n *yaml.Node) error {
+	r.Pos = posOf(n)
+	if n.Kind == yaml.ScalarNode {
+		r.Job = n.Value
+		return nil
+	}
+	if n.Kind != yaml.MappingNode || len(n.Content) < 2 {
+		return errorf(n, "requirement must be a job name or a mapping of a job name to statuses")
+	}
+	r.Job = n.Content[0].Value
+	if err := n.Content[1].Decode(&r.Status); err != nil {
+		return err
+	}
+	for i := 2; i+1 < len(n.Content); i += 2 {
+		var value interface{}
+		if err := n.Content[i+1].Decode(&value); err != nil {
+			return err
+		}
+		if r.Extra == nil {
+			r.Extra = map[string]interface{}{}
+		}
+		r.Extra[n.Content[i].Value] = value
+	}
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (r *Requirement) MarshalYAML() (interface{}, error) {
+	if len(r.Extra) == 0 {
+		if len(r.Status) == 0 {
+			return r.Job, nil
+		}
+		return map[string]Strings{r.Job: r.Status}, nil
+	}
+	// The job name must come first, so the mapping is built in order.
+	n := &yaml.Node{Kind: yaml.MappingNode}
+	if err := appendMapping(n, r.Job, r.Status); err != nil {
+		return nil, err
+	}
+	for _, k := range sortedKeys(r.Extra) {
+		if err := appendMapping(n, k, r.Extra[k]); err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+// appendMapping appends the key and the value to the mapping node.
+func appendMapping(n *yaml.Node, key string, value interface{}) error {
+	v := &yaml.Node{}
+	if err := v.Encode(value); err != nil {
+		return err
+	}
+	n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, v)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (f *Filters) UnmarshalYAML(n *yaml.Node) error {
+	type plain Filters
+	if err := n.Decode((*plain)(f)); err != nil {
+		return err
+	}
+	f.Pos = posOf(n)
+	return nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (m *Matrix) UnmarshalYAML(n *yaml.Node) error {
+	type plain Matrix
+	if err := n.Decode((*plain)(m)); err != nil {
+		return err
+	}
+	m.Pos = posOf(n)
+	return nil
+}
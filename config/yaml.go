@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Pos represents a position in the config file.
+type Pos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+func posOf(n *yaml.Node) Pos {
+	return Pos{Line: n.Line, Column: n.Column}
+}
+
+// Error represents an error in the config with its position.
+type Error struct {
+	File string
+	Pos
+	Message string
+}
+
+func (e *Error) Error() string {
+	s := e.Message
+	if e.Line > 0 {
+		s = fmt.Sprintf("%d:%d: %s", e.Line, e.Column, s)
+	}
+	if e.File != "" {
+		s = e.File + ":" + s
+	}
+	return s
+}
+
+func errorf(n *yaml.Node, format string, a ...interface{}) error {
+	return &Error{Pos: posOf(n), Message: fmt.Sprintf(format, a...)}
+}
+
+// Scalar represents a scalar value which can also be an expression such as << parameters.n >>,
+// e.g. version and parallelism. Value is kept as it is written in the source, with its Tag and Style
+// to write it back in the same form, e.g. "1" quoted stays a string.
+// Tag and Style are resolved from Value if empty.
+type Scalar struct {
+	Value string
+	Tag   string
+	Style yaml.Style
+}
+
+// String returns the value.
+func (s Scalar) String() string {
+	return s.Value
+}
+
+// IsZero reports whether the value is empty, to omit it in YAML.
+func (s Scalar) IsZero() bool {
+	return s.Value == "" && s.Tag == ""
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *Scalar) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.ScalarNode {
+		return errorf(n, "expected a scalar value")
+	}
+	*s = Scalar{Value: n.Value, Tag: n.Tag, Style: n.Style}
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler. The value is written in the same form as the source.
+func (s Scalar) MarshalYAML() (interface{}, error) {
+	if s.IsZero() {
+		return "", nil
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: s.Value, Tag: s.Tag, Style: s.Style}, nil
+}
+
+// Strings represents a list of strings which can also be written as a single string.
+type Strings []string
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *Strings) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		*s = Strings{n.Value}
+		return nil
+	}
+	var ss []string
+	if err := n.Decode(&ss); err != nil {
+		return err
+	}
+	*s = ss
+	return nil
+}
+
+// mappingKeys calls fn for each key and value of the mapping node in order.
+func mappingKeys(n *yaml.Node, fn func(key string, k, v *yaml.Node) error) error {
+	if n.Kind != yaml.MappingNode {
+		return errorf(n, "expected a mapping")
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if err := fn(n.Content[i].Value, n.Content[i], n.Content[i+1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of the map with string keys in order.
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	ss := make([]string, 0, len(keys))
+	for _, k := range keys {
+		ss = append(ss, k.String())
+	}
+	sort.Strings(ss)
+	return ss
+}
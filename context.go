@@ -11,10 +11,12 @@ const (
 // ContextService is an interface for Context in Project API.
 type ContextService interface {
 	List(slug string) (*ContextList, error)
+	ListAll(slug string) ([]*Context, error)
 	Create(projectSlug, name string) (*Context, error)
 	Delete(id string) error
 	Get(id string) (*Context, error)
 	ListEnvVar(id string) (*ContextEnvVarList, error)
+	ListAllEnvVar(id string) ([]*ContextEnvVar, error)
 	UpsertEnvVar(id, envVarName, envVarValue string) (*ContextEnvVar, error)
 	RemoveEnvVar(id, envVarName string) error
 }
@@ -55,6 +57,7 @@ type ContextCreate struct {
 type ContextEnvVar struct {
 	Variable  string    `json:"variable,omitempty"`
 	CreatedAt time.Time `json:"created_at,omitempty"`
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
 	ContextID string    `json:"context_id,omitempty"`
 }
 
@@ -76,6 +79,25 @@ func (ps *ContextOp) List(slug string) (*ContextList, error) {
 	return cl, nil
 }
 
+// ListAll lists all contexts for an owner by following every page.
+func (ps *ContextOp) ListAll(slug string) ([]*Context, error) {
+	var items []*Context
+	path := contextBasePath + "?owner-slug=" + slug
+	opts := &PageOptions{}
+	for {
+		cl := &ContextList{}
+		err := ps.client.Get(path, cl, opts)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, cl.Items...)
+		if cl.NextPageToken == "" {
+			return items, nil
+		}
+		opts.PageToken = cl.NextPageToken
+	}
+}
+
 // Create adds a new environment variable or update existing variable on the specified project.
 // Returns the added env var (the value will be masked).
 func (ps *ContextOp) Create(projectSlug, name string) (*Context, error) {
@@ -114,6 +136,24 @@ func (ps *ContextOp) ListEnvVar(id string) (*ContextEnvVarList, error) {
 	return cel, nil
 }
 
+// ListAllEnvVar lists all environment variables of the context by following every page.
+func (ps *ContextOp) ListAllEnvVar(id string) ([]*ContextEnvVar, error) {
+	var items []*ContextEnvVar
+	opts := &PageOptions{}
+	for {
+		cel := &ContextEnvVarList{}
+		err := ps.client.Get(contextEnvVarPath(id), cel, opts)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, cel.Items...)
+		if cel.NextPageToken == "" {
+			return items, nil
+		}
+		opts.PageToken = cel.NextPageToken
+	}
+}
+
 // UpsertEnvVar list contexts for an owner.
 // Returns the env vars (the value will be masked).
 func (ps *ContextOp) UpsertEnvVar(id, envVarName, envVarValue string) (*ContextEnvVar, error) {
@@ -0,0 +1,196 @@
+package circleci
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RotationOptions specifies how RotateContextEnvVar rotates a variable.
+type RotationOptions struct {
+	// Name is the name of the variable to rotate. Required.
+	Name string
+	// Value is the new value of the variable. Required.
+	Value string
+	// Owners are the owner slugs, e.g. gh/org, to look for contexts in. Defaults to the collaborations of the user.
+	Owners []string
+	// ContextIDs limits the contexts to rotate the variable in. All contexts containing the variable if empty.
+	ContextIDs []string
+	// VerifyProject is the project slug to trigger a pipeline of after rotation succeeded. No pipeline is triggered if empty.
+	VerifyProject string
+	// VerifyPipeline is the parameters of the pipeline to trigger.
+	VerifyPipeline *PipelineTriggerOptions
+	// DryRun makes RotateContextEnvVar only report the contexts the variable would be rotated in.
+	DryRun bool
+}
+
+// RotationResult represents result of rotating a variable in a context.
+// PreviousCreatedAt and PreviousUpdatedAt are the timestamps of the variable before rotation.
+type RotationResult struct {
+	Owner             string    `json:"owner"`
+	ContextID         string    `json:"context_id"`
+	ContextName       string    `json:"context_name"`
+	PreviousCreatedAt time.Time `json:"previous_created_at,omitempty"`
+	PreviousUpdatedAt time.Time `json:"previous_updated_at,omitempty"`
+	UpdatedAt         time.Time `json:"updated_at,omitempty"`
+	Rotated           bool      `json:"rotated"`
+	Verified          bool      `json:"verified"`
+	Error             string    `json:"error,omitempty"`
+	Err               error     `json:"-"`
+}
+
+// RotationReport represents result of RotateContextEnvVar.
+// Results are in the order the variable was rotated in.
+type RotationReport struct {
+	Name          string            `json:"name"`
+	StartedAt     time.Time         `json:"started_at"`
+	DryRun        bool              `json:"dry_run,omitempty"`
+	Results       []*RotationResult `json:"results"`
+	Pipeline      *PipelineTrigger  `json:"pipeline,omitempty"`
+	PipelineError string            `json:"pipeline_error,omitempty"`
+}
+
+// Succeeded reports whether the variable was rotated and verified in every context.
+func (r *RotationReport) Succeeded() bool {
+	for _, res := range r.Results {
+		if !res.Rotated || !res.Verified {
+			return false
+		}
+	}
+	return true
+}
+
+// RollbackOptions returns RotationOptions to put the previous value back to the contexts the variable was rotated in.
+// The previous value must be given since CircleCI never returns values of variables.
+func (r *RotationReport) RollbackOptions(previousValue string) *RotationOptions {
+	opts := &RotationOptions{Name: r.Name, Value: previousValue}
+	for _, res := range r.Results {
+		if res.Rotated {
+			opts.ContextIDs = append(opts.ContextIDs, res.ContextID)
+			if !containsString(opts.Owners, res.Owner) {
+				opts.Owners = append(opts.Owners, res.Owner)
+			}
+		}
+	}
+	return opts
+}
+
+// RotateContextEnvVar sets a new value to the variable in every context containing it,
+// then verifies that the variable is still there and its timestamp did not go back.
+// Errors of individual contexts are stored in each RotationResult, and a pipeline is triggered
+// to verify the new value only when every context succeeded.
+// An error is returned when the contexts could not be enumerated or the token is rejected,
+// along with the report of the contexts processed so far.
+func (c *Client) RotateContextEnvVar(opts *RotationOptions) (*RotationReport, error) {
+	if opts == nil || opts.Name == "" || opts.Value == "" {
+		return nil, errors.New("name and value of the variable are required")
+	}
+	owners, err := c.auditOwners(opts.Owners)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RotationReport{Name: opts.Name, StartedAt: time.Now(), DryRun: opts.DryRun}
+	targets, err := c.rotationTargets(owners, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		r.Results = targets
+		return r, nil
+	}
+
+	for _, t := range targets {
+		r.Results = append(r.Results, t)
+		if err := c.rotate(t, opts.Name, opts.Value); err != nil {
+			t.Err, t.Error = err, err.Error()
+			if IsFatalError(err) {
+				return r, err
+			}
+		}
+	}
+
+	if opts.VerifyProject != "" && len(r.Results) != 0 && r.Succeeded() {
+		p, err := c.Pipeline.Trigger(opts.VerifyProject, opts.VerifyPipeline)
+		if err != nil {
+			r.PipelineError = err.Error()
+		}
+		r.Pipeline = p
+	}
+	return r, nil
+}
+
+func (c *Client) rotationTargets(owners []string, opts *RotationOptions) ([]*RotationResult, error) {
+	var targets []*RotationResult
+	for _, owner := range owners {
+		cs, err := c.Context.ListAll(owner)
+		if err != nil {
+			return nil, err
+		}
+		for _, cx := range cs {
+			if len(opts.ContextIDs) != 0 && !containsString(opts.ContextIDs, cx.ID) {
+				continue
+			}
+			ev, err := c.contextEnvVar(cx.ID, opts.Name)
+			if err != nil {
+				return nil, err
+			}
+			if ev == nil {
+				continue
+			}
+			targets = append(targets, &RotationResult{
+				Owner:             owner,
+				ContextID:         cx.ID,
+				ContextName:       cx.Name,
+				PreviousCreatedAt: ev.CreatedAt,
+				PreviousUpdatedAt: ev.UpdatedAt,
+			})
+		}
+	}
+	return targets, nil
+}
+
+// rotate upserts the value and verifies the variable was updated.
+func (c *Client) rotate(t *RotationResult, name, value string) error {
+	if _, err := c.Context.UpsertEnvVar(t.ContextID, name, value); err != nil {
+		return err
+	}
+	t.Rotated = true
+
+	ev, err := c.contextEnvVar(t.ContextID, name)
+	if err != nil {
+		return err
+	}
+	if ev == nil {
+		return fmt.Errorf("variable %s not found in context %s after rotation", name, t.ContextName)
+	}
+	t.UpdatedAt = envVarTimestamp(ev.CreatedAt, ev.UpdatedAt)
+	// Timestamps are in seconds, so the one of an update in the same second as the previous one does not advance.
+	if t.UpdatedAt.Before(envVarTimestamp(t.PreviousCreatedAt, t.PreviousUpdatedAt)) {
+		return fmt.Errorf("timestamp of variable %s in context %s went back after rotation", name, t.ContextName)
+	}
+	t.Verified = true
+	return nil
+}
+
+// contextEnvVar finds the variable in the context. nil is returned if not found.
+func (c *Client) contextEnvVar(id, name string) (*ContextEnvVar, error) {
+	evs, err := c.Context.ListAllEnvVar(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, ev := range evs {
+		if ev.Variable == name {
+			return ev, nil
+		}
+	}
+	return nil, nil
+}
+
+// envVarTimestamp returns when the variable was last modified. Older API does not return updated_at.
+func envVarTimestamp(createdAt, updatedAt time.Time) time.Time {
+	if updatedAt.After(createdAt) {
+		return updatedAt
+	}
+	return createdAt
+}
@@ -0,0 +1,88 @@
+package circleci_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+func TestClient_RotateContextEnvVar(t *testing.T) {
+	var mu sync.Mutex
+	// The variable in c3 is updated in the same second as it was created.
+	sameSecond := time.Now().Truncate(time.Second)
+	updatedAt := map[string]time.Time{
+		"c1": time.Now().Add(-time.Hour),
+		"c3": sameSecond,
+	}
+	var triggered *circleci.PipelineTriggerOptions
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/context", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items": [{"id": "c1", "name": "aws"}, {"id": "c2", "name": "docker"}, {"id": "c3", "name": "deploy"}]}`)
+	})
+	for _, id := range []string{"c1", "c2", "c3"} {
+		id := id
+		mux.HandleFunc("/api/v2/context/"+id+"/environment-variable", func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			items := []*circleci.ContextEnvVar{{Variable: "OTHER", ContextID: id}}
+			if u, ok := updatedAt[id]; ok {
+				items = append(items, &circleci.ContextEnvVar{Variable: "AWS_SECRET_ACCESS_KEY", ContextID: id, CreatedAt: u, UpdatedAt: u})
+			}
+			json.NewEncoder(w).Encode(&circleci.ContextEnvVarList{Items: items})
+		})
+		mux.HandleFunc("/api/v2/context/"+id+"/environment-variable/AWS_SECRET_ACCESS_KEY", func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPut {
+				t.Errorf("Invalid method. Expected: PUT, Actual: %s", r.Method)
+			}
+			mu.Lock()
+			if id == "c3" {
+				updatedAt[id] = sameSecond
+			} else {
+				updatedAt[id] = time.Now()
+			}
+			mu.Unlock()
+			fmt.Fprintf(w, `{"variable": "AWS_SECRET_ACCESS_KEY", "context_id": %q}`, id)
+		})
+	}
+	mux.HandleFunc("/api/v2/project/gh/org/repo/pipeline", func(w http.ResponseWriter, r *http.Request) {
+		triggered = &circleci.PipelineTriggerOptions{}
+		json.NewDecoder(r.Body).Decode(triggered)
+		fmt.Fprint(w, `{"id": "p1", "number": 10, "state": "pending"}`)
+	})
+	c := newTestClient(t, mux)
+
+	r, err := c.RotateContextEnvVar(&circleci.RotationOptions{
+		Name:           "AWS_SECRET_ACCESS_KEY",
+		Value:          "new",
+		Owners:         []string{"gh/org"},
+		VerifyProject:  "gh/org/repo",
+		VerifyPipeline: &circleci.PipelineTriggerOptions{Branch: "main"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Succeeded() || len(r.Results) != 2 {
+		t.Fatalf("Invalid report. Actual: %+v", r.Results)
+	}
+	if r.Pipeline == nil || r.Pipeline.Number != 10 || triggered == nil || triggered.Branch != "main" {
+		t.Errorf("Verification pipeline must be triggered. Actual: %+v", r.Pipeline)
+	}
+
+	rollback := r.RollbackOptions("old")
+	expected := &circleci.RotationOptions{
+		Name:       "AWS_SECRET_ACCESS_KEY",
+		Value:      "old",
+		Owners:     []string{"gh/org"},
+		ContextIDs: []string{"c1", "c3"},
+	}
+	if !reflect.DeepEqual(rollback, expected) {
+		t.Errorf("Invalid rollback options. Expected: %+v, Actual: %+v", expected, rollback)
+	}
+}
@@ -0,0 +1,312 @@
+package circleci
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const defaultStaleAfter = 365 * 24 * time.Hour
+
+// Scope where an environment variable is defined.
+const (
+	EnvVarScopeProject = "project"
+	EnvVarScopeContext = "context"
+)
+
+// Kind of EnvVarFinding.
+const (
+	// EnvVarFindingDuplicate is a variable defined in more than one project or more than one context of an owner.
+	EnvVarFindingDuplicate = "duplicate"
+	// EnvVarFindingProjectAndContext is a variable defined in both a project and a context of the same owner.
+	EnvVarFindingProjectAndContext = "project_and_context"
+	// EnvVarFindingRiskyName is a variable whose name matches one of the risky patterns.
+	EnvVarFindingRiskyName = "risky_name"
+	// EnvVarFindingStale is a context variable created before the stale threshold.
+	EnvVarFindingStale = "stale"
+)
+
+// DefaultRiskyEnvVarPatterns are the patterns of variable names which likely hold credentials.
+var DefaultRiskyEnvVarPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)^AWS_(ACCESS_KEY_ID|SECRET_ACCESS_KEY|SESSION_TOKEN)$`),
+	regexp.MustCompile(`(?i)TOKEN`),
+	regexp.MustCompile(`(?i)SECRET`),
+	regexp.MustCompile(`(?i)PASSW(OR)?D`),
+	regexp.MustCompile(`(?i)PRIVATE_KEY`),
+	regexp.MustCompile(`(?i)API_?KEY`),
+}
+
+// EnvVarAuditOptions specifies what AuditEnvVars looks into.
+type EnvVarAuditOptions struct {
+	// Owners are the owner slugs, e.g. gh/org, to audit contexts of. Defaults to the collaborations of the user.
+	Owners []string
+	// Projects are the project slugs to audit. Defaults to the projects followed by the user.
+	Projects []string
+	// RiskyPatterns are the patterns of risky variable names. Defaults to DefaultRiskyEnvVarPatterns.
+	RiskyPatterns []*regexp.Regexp
+	// StaleAfter is the age of context variables to report as stale. Defaults to 365 days.
+	StaleAfter time.Duration
+	// Concurrency is the maximum number of requests in flight. Defaults to 4.
+	Concurrency int
+}
+
+// EnvVarLocation represents where an environment variable is defined.
+// Project is set for project variables, ContextID and ContextName for context variables.
+// CreatedAt is only known for context variables.
+type EnvVarLocation struct {
+	Name        string    `json:"name"`
+	Scope       string    `json:"scope"`
+	Owner       string    `json:"owner"`
+	Project     string    `json:"project,omitempty"`
+	ContextID   string    `json:"context_id,omitempty"`
+	ContextName string    `json:"context_name,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
+
+// EnvVarFinding represents an issue found by AuditEnvVars.
+type EnvVarFinding struct {
+	Kind      string           `json:"kind"`
+	Name      string           `json:"name"`
+	Owner     string           `json:"owner"`
+	Detail    string           `json:"detail,omitempty"`
+	Locations []EnvVarLocation `json:"locations"`
+}
+
+// EnvVarAuditError represents a project or a context which could not be audited.
+type EnvVarAuditError struct {
+	Target string `json:"target"`
+	Error  string `json:"error"`
+}
+
+// EnvVarAuditReport represents result of AuditEnvVars.
+type EnvVarAuditReport struct {
+	GeneratedAt time.Time          `json:"generated_at"`
+	Variables   []EnvVarLocation   `json:"variables"`
+	Findings    []EnvVarFinding    `json:"findings"`
+	Errors      []EnvVarAuditError `json:"errors,omitempty"`
+}
+
+// AuditEnvVars lists environment variables of projects and contexts the token can see and reports
+// duplicates, variables defined in both a project and a context, risky names and stale variables.
+// Values are never read. Projects or contexts failed to list are recorded in Errors of the report,
+// while an error is returned when the targets could not be enumerated or the token is rejected.
+func (c *Client) AuditEnvVars(opts *EnvVarAuditOptions) (*EnvVarAuditReport, error) {
+	if opts == nil {
+		opts = &EnvVarAuditOptions{}
+	}
+	owners, err := c.auditOwners(opts.Owners)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := c.auditProjects(opts.Projects)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &EnvVarAuditReport{GeneratedAt: time.Now()}
+	b := &Batch{Concurrency: opts.Concurrency}
+
+	projectResults, err := b.Run(context.Background(), len(projects), func(ctx context.Context, i int) (interface{}, error) {
+		return c.EnvVar.ListAll(projects[i].String())
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, res := range projectResults {
+		p := projects[i]
+		if res.Err != nil {
+			r.Errors = append(r.Errors, EnvVarAuditError{Target: p.String(), Error: res.Err.Error()})
+			continue
+		}
+		for _, ev := range res.Value.([]*ProjectEnvVar) {
+			r.Variables = append(r.Variables, EnvVarLocation{
+				Name:    ev.Name,
+				Scope:   EnvVarScopeProject,
+				Owner:   p.Provider + "/" + p.Org,
+				Project: p.String(),
+			})
+		}
+	}
+
+	var contexts []EnvVarLocation
+	for _, owner := range owners {
+		cs, err := c.Context.ListAll(owner)
+		if err != nil {
+			if IsFatalError(err) {
+				return nil, err
+			}
+			r.Errors = append(r.Errors, EnvVarAuditError{Target: owner, Error: err.Error()})
+			continue
+		}
+		for _, cx := range cs {
+			contexts = append(contexts, EnvVarLocation{Owner: normalizeOwner(owner), ContextID: cx.ID, ContextName: cx.Name})
+		}
+	}
+	contextResults, err := b.Run(context.Background(), len(contexts), func(ctx context.Context, i int) (interface{}, error) {
+		return c.Context.ListAllEnvVar(contexts[i].ContextID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, res := range contextResults {
+		cx := contexts[i]
+		if res.Err != nil {
+			r.Errors = append(r.Errors, EnvVarAuditError{Target: cx.Owner + "/" + cx.ContextName, Error: res.Err.Error()})
+			continue
+		}
+		for _, ev := range res.Value.([]*ContextEnvVar) {
+			loc := cx
+			loc.Name = ev.Variable
+			loc.Scope = EnvVarScopeContext
+			loc.CreatedAt = ev.CreatedAt
+			r.Variables = append(r.Variables, loc)
+		}
+	}
+
+	patterns := opts.RiskyPatterns
+	if patterns == nil {
+		patterns = DefaultRiskyEnvVarPatterns
+	}
+	staleAfter := opts.StaleAfter
+	if staleAfter <= 0 {
+		staleAfter = defaultStaleAfter
+	}
+	r.Findings = auditFindings(r.Variables, patterns, r.GeneratedAt.Add(-staleAfter))
+	return r, nil
+}
+
+func (c *Client) auditOwners(owners []string) ([]string, error) {
+	if len(owners) != 0 {
+		return owners, nil
+	}
+	cs, err := c.User.Collaborations()
+	if err != nil {
+		return nil, err
+	}
+	for _, co := range cs {
+		owners = append(owners, co.Slug)
+	}
+	return owners, nil
+}
+
+func (c *Client) auditProjects(projectSlugs []string) ([]Slug, error) {
+	var projects []Slug
+	if len(projectSlugs) != 0 {
+		for _, s := range projectSlugs {
+			slug, err := ParseSlug(s)
+			if err != nil {
+				return nil, err
+			}
+			projects = append(projects, slug)
+		}
+		return projects, nil
+	}
+
+	ps, err := c.V1.Projects()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range ps {
+		projects = append(projects, Slug{Provider: p.VcsType, Org: p.Username, Repo: p.Reponame}.Normalize())
+	}
+	return projects, nil
+}
+
+// normalizeOwner returns the owner slug with the provider in short name, e.g. github/org to gh/org.
+func normalizeOwner(owner string) string {
+	i := strings.Index(owner, "/")
+	if i <= 0 {
+		return owner
+	}
+	return Slug{Provider: owner[:i]}.Normalize().Provider + owner[i:]
+}
+
+func auditFindings(vars []EnvVarLocation, patterns []*regexp.Regexp, staleBefore time.Time) []EnvVarFinding {
+	type key struct{ owner, name string }
+	groups := map[key][]EnvVarLocation{}
+	var keys []key
+	for _, v := range vars {
+		k := key{owner: v.Owner, name: v.Name}
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], v)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].owner != keys[j].owner {
+			return keys[i].owner < keys[j].owner
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	var findings []EnvVarFinding
+	for _, k := range keys {
+		locs := groups[k]
+		var projects, contexts []EnvVarLocation
+		for _, l := range locs {
+			if l.Scope == EnvVarScopeProject {
+				projects = append(projects, l)
+			} else {
+				contexts = append(contexts, l)
+			}
+		}
+		add := func(kind, detail string, locs []EnvVarLocation) {
+			findings = append(findings, EnvVarFinding{Kind: kind, Name: k.name, Owner: k.owner, Detail: detail, Locations: locs})
+		}
+
+		if len(projects) > 1 || len(contexts) > 1 {
+			add(EnvVarFindingDuplicate, fmt.Sprintf("defined in %d projects and %d contexts", len(projects), len(contexts)), locs)
+		}
+		if len(projects) > 0 && len(contexts) > 0 {
+			add(EnvVarFindingProjectAndContext, "project variables take precedence over context variables", locs)
+		}
+		for _, p := range patterns {
+			if p.MatchString(k.name) {
+				add(EnvVarFindingRiskyName, "matches "+p.String(), locs)
+				break
+			}
+		}
+		for _, l := range contexts {
+			if !l.CreatedAt.IsZero() && l.CreatedAt.Before(staleBefore) {
+				add(EnvVarFindingStale, "created at "+l.CreatedAt.Format(time.RFC3339), []EnvVarLocation{l})
+			}
+		}
+	}
+	return findings
+}
+
+// WriteEnvVarAuditJSON writes the report to w in JSON format.
+func WriteEnvVarAuditJSON(w io.Writer, r *EnvVarAuditReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteEnvVarAuditCSV writes the findings of the report to w in CSV format, a row for each location of a finding.
+func WriteEnvVarAuditCSV(w io.Writer, r *EnvVarAuditReport) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"kind", "name", "owner", "scope", "project", "context_id", "context_name", "created_at", "detail"})
+	if err != nil {
+		return err
+	}
+	for _, f := range r.Findings {
+		for _, l := range f.Locations {
+			createdAt := ""
+			if !l.CreatedAt.IsZero() {
+				createdAt = l.CreatedAt.Format(time.RFC3339)
+			}
+			err := cw.Write([]string{f.Kind, f.Name, f.Owner, l.Scope, l.Project, l.ContextID, l.ContextName, createdAt, f.Detail})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
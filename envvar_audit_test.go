@@ -0,0 +1,95 @@
+package circleci_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+func TestClient_AuditEnvVars(t *testing.T) {
+	old := time.Now().AddDate(-2, 0, 0).Format(time.RFC3339)
+	recent := time.Now().Format(time.RFC3339)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/me/collaborations", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id": "o1", "vcs-type": "github", "name": "org", "slug": "github/org"}]`)
+	})
+	mux.HandleFunc("/api/v1.1/projects", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"username": "org", "reponame": "api", "vcs_type": "github"},
+			{"username": "org", "reponame": "web", "vcs_type": "github"}
+		]`)
+	})
+	mux.HandleFunc("/api/v2/project/gh/org/api/envvar", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items": [{"name": "AWS_SECRET_ACCESS_KEY", "value": "xxxx"}, {"name": "LOG_LEVEL", "value": "xxxx"}]}`)
+	})
+	mux.HandleFunc("/api/v2/project/gh/org/web/envvar", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Project not found"}`)
+	})
+	mux.HandleFunc("/api/v2/context", func(w http.ResponseWriter, r *http.Request) {
+		if slug := r.URL.Query().Get("owner-slug"); slug != "github/org" {
+			t.Errorf("Invalid owner slug. Actual: %s", slug)
+		}
+		if r.URL.Query().Get("page-token") == "" {
+			fmt.Fprint(w, `{"items": [{"id": "c1", "name": "aws"}], "next_page_token": "p2"}`)
+			return
+		}
+		fmt.Fprint(w, `{"items": [{"id": "c2", "name": "deploy"}]}`)
+	})
+	mux.HandleFunc("/api/v2/context/c1/environment-variable", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"items": [{"variable": "AWS_SECRET_ACCESS_KEY", "created_at": %q, "context_id": "c1"}]}`, old)
+	})
+	mux.HandleFunc("/api/v2/context/c2/environment-variable", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"items": [{"variable": "AWS_SECRET_ACCESS_KEY", "created_at": %q, "context_id": "c2"}]}`, recent)
+	})
+	c := newTestClient(t, mux)
+
+	r, err := c.AuditEnvVars(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Variables) != 4 {
+		t.Errorf("Invalid number of variables. Expected: 4, Actual: %d", len(r.Variables))
+	}
+	if len(r.Errors) != 1 || r.Errors[0].Target != "gh/org/web" {
+		t.Errorf("Invalid errors. Actual: %+v", r.Errors)
+	}
+
+	kinds := map[string]int{}
+	for _, f := range r.Findings {
+		if f.Name != "AWS_SECRET_ACCESS_KEY" || f.Owner != "gh/org" {
+			t.Errorf("Unexpected finding. Actual: %+v", f)
+		}
+		kinds[f.Kind]++
+	}
+	expected := map[string]int{
+		circleci.EnvVarFindingDuplicate:         1,
+		circleci.EnvVarFindingProjectAndContext: 1,
+		circleci.EnvVarFindingRiskyName:         1,
+		circleci.EnvVarFindingStale:             1,
+	}
+	for k, n := range expected {
+		if kinds[k] != n {
+			t.Errorf("Invalid number of %s findings. Expected: %d, Actual: %d", k, n, kinds[k])
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := circleci.WriteEnvVarAuditCSV(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Header, 3 locations for duplicate, project_and_context and risky_name each, and 1 for stale.
+	if len(rows) != 1+3*3+1 {
+		t.Errorf("Invalid number of CSV rows. Actual: %d", len(rows))
+	}
+}
@@ -2,6 +2,7 @@ package circleci_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"log"
 	"os"
@@ -83,6 +84,27 @@ func ExampleContextOp_Create() {
 
 }
 
+func ExampleBatch_Run() {
+	token := os.Getenv("CIRCLECI_TOKEN")
+	client := circleci.NewClient(token, circleci.WithRateLimiter(circleci.NewRateLimiter(10, 5)))
+
+	slugs := []string{projectSlug(), circleci.ProjectSlug("gh", "ttyfky", "other")}
+	batch := &circleci.Batch{Concurrency: 4, Retries: 2}
+	results, err := batch.Run(context.Background(), len(slugs), func(ctx context.Context, i int) (interface{}, error) {
+		return client.EnvVar.List(slugs[i])
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			println(slugs[r.Index], r.Err.Error())
+			continue
+		}
+		printPretty(r.Value)
+	}
+}
+
 func projectSlug() string {
 	projectType := "gh"
 	org := "ttyfky"
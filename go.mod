@@ -2,4 +2,7 @@ module github.com/ttyfky/go-circleci
 
 go 1.15
 
-require github.com/google/go-querystring v1.0.0
+require (
+	github.com/google/go-querystring v1.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
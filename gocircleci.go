@@ -2,8 +2,10 @@ package circleci
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -11,6 +13,7 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/go-querystring/query"
@@ -19,20 +22,25 @@ import (
 const (
 	UserAgent = "gocircleci/1.0.0"
 
-	queryLimit         = 100 // maximum that CircleCI allows
-	defaultHTTPTimeout = 20
-	defaultPathPrefix  = "/api/v2/"
+	queryLimit          = 100 // maximum that CircleCI allows
+	defaultHTTPTimeout  = 20
+	defaultPathPrefix   = "/api/v2/"
+	defaultV1PathPrefix = "/api/v1.1/"
+	runnerPathPrefix    = "/api/v3/"
 )
 
 var (
-	defaultBaseURL = &url.URL{Host: "circleci.com", Scheme: "https"}
-	defaultLogger  = log.New(os.Stderr, "", log.LstdFlags)
+	defaultBaseURL       = &url.URL{Host: "circleci.com", Scheme: "https"}
+	defaultRunnerBaseURL = &url.URL{Host: "runner.circleci.com", Scheme: "https"}
+	defaultLogger        = log.New(os.Stderr, "", log.LstdFlags)
 )
 
 // APIError represents an error from CircleCI
 type APIError struct {
 	HTTPStatusCode int
 	Message        string
+	// Response is the response of the error (nil if the error is not from a response)
+	Response *Response
 }
 
 func (e *APIError) Error() string {
@@ -42,29 +50,64 @@ func (e *APIError) Error() string {
 // Client is a CircleCI client.
 type Client struct {
 	// CircleCI API endpoint (defaults to DefaultEndpoint)
-	BaseURL    *url.URL
-	pathPrefix string
+	BaseURL *url.URL
+	// Base URL of the self-hosted runner API (derived from BaseURL if nil, i.e. runner.circleci.com for circleci.com, or BaseURL otherwise)
+	RunnerURL *url.URL
+	// Base URL of the webhook API (BaseURL if nil)
+	WebhookURL   *url.URL
+	pathPrefix   string
+	v1PathPrefix string
 	// HTTPClient to use for connecting to CircleCI (defaults to http.DefaultClient)
 	HTTPClient *http.Client
-	token      string
+	// Authenticator of requests (nil for anonymous client)
+	auth  Authenticator
+	hooks []Hook
+	cache *Cache
+	// maxBodySize is the maximum size of response body to read (no limit if not positive)
+	maxBodySize int64
+
+	limiter RateLimiter
+
+	mu           sync.Mutex
+	lastResponse *Response
+	pausedUntil  time.Time
 
 	Project  ProjectService
 	EnvVar   ProjectEnvVarService
 	Workflow WorkflowService
 	Job      JobService
 	Context  ContextService
+	Pipeline PipelineService
+	JobLog   JobLogService
+	User     UserService
+	Orb      OrbService
+	Usage    UsageService
+	OIDC     OIDCService
+	V1       V1Service
 }
 
 // NewClient creates new CircleCI client with given API token.
 // Optionally HTTP client or some other fields can be also given.
+// The token is sent in Circle-Token header unless other Authenticator is given with WithAuthenticator.
 func NewClient(token string, opts ...Option) *Client {
+	return newClient(&APIKeyAuth{Source: StaticToken(token)}, opts...)
+}
+
+// NewAnonymousClient creates new CircleCI client without authentication, which can only call public endpoints.
+func NewAnonymousClient(opts ...Option) *Client {
+	return newClient(nil, opts...)
+}
+
+func newClient(auth Authenticator, opts ...Option) *Client {
 	c := &Client{
 		BaseURL: defaultBaseURL,
 		HTTPClient: &http.Client{
 			Timeout: time.Second * defaultHTTPTimeout,
 		},
-		token:      token,
-		pathPrefix: defaultPathPrefix,
+		auth:         auth,
+		maxBodySize:  defaultMaxBodySize,
+		pathPrefix:   defaultPathPrefix,
+		v1PathPrefix: defaultV1PathPrefix,
 	}
 
 	// Apply options for client.
@@ -77,9 +120,35 @@ func NewClient(token string, opts ...Option) *Client {
 	c.Workflow = &WorkflowOp{client: c}
 	c.Job = &JobOp{client: c}
 	c.Context = &ContextOp{client: c}
+	c.Pipeline = &PipelineOp{client: c}
+	c.JobLog = &JobLogOp{client: c}
+	c.User = &UserOp{client: c}
+	c.Orb = &OrbOp{client: c}
+	c.Usage = &UsageOp{client: c}
+	c.OIDC = &OIDCOp{client: c}
+	c.V1 = &V1Op{client: c}
 	return c
 }
 
+// runnerURL returns RunnerURL, or the one derived from the current BaseURL if it is not set.
+func (c *Client) runnerURL() *url.URL {
+	if c.RunnerURL != nil {
+		return c.RunnerURL
+	}
+	if c.BaseURL.Host == defaultBaseURL.Host {
+		return defaultRunnerBaseURL
+	}
+	return c.BaseURL
+}
+
+// webhookURL returns WebhookURL, or the current BaseURL if it is not set.
+func (c *Client) webhookURL() *url.URL {
+	if c.WebhookURL != nil {
+		return c.WebhookURL
+	}
+	return c.BaseURL
+}
+
 // NewRequest creates a new http.Request with given parameters.
 func (c *Client) NewRequest(method, path string, body, opts interface{}) (req *http.Request, err error) {
 	rel, err := url.Parse(path)
@@ -121,10 +190,10 @@ func (c *Client) NewRequest(method, path string, body, opts interface{}) (req *h
 	req.Header.Add("Content-Type", "application/json")
 	req.Header.Add("Accept", "application/json")
 	req.Header.Add("User-Agent", UserAgent)
-	if c.token != "" {
-		req.Header.Add("Circle-Token", c.token)
-	} else {
-		return nil, fmt.Errorf("API token must not be blank")
+	if c.auth != nil {
+		if err := c.auth.Authenticate(req); err != nil {
+			return nil, err
+		}
 	}
 	return req, nil
 }
@@ -136,62 +205,223 @@ func (c *Client) NewRequest(method, path string, body, opts interface{}) (req *h
 // The options argument is used for specifying request options.
 // Any data returned from CircleCI will be marshalled into resource argument.
 func (c *Client) CreateAndDo(method, relPath string, data, options, resource interface{}) error {
+	return c.createAndDo(c.pathPrefix, method, relPath, data, options, resource)
+}
+
+// CreateAndDoWithResponse performs a web request same as CreateAndDo, and returns the Response
+// to see its status, headers and rate limit.
+func (c *Client) CreateAndDoWithResponse(method, relPath string, data, options, resource interface{}) (*Response, error) {
+	return c.createAndDoWithResponse(c.pathPrefix, method, relPath, data, options, resource)
+}
+
+// createAndDo performs a web request to CircleCI with the relative path under the given API prefix.
+func (c *Client) createAndDo(prefix, method, relPath string, data, options, resource interface{}) error {
+	_, err := c.createAndDoWithResponse(prefix, method, relPath, data, options, resource)
+	return err
+}
+
+func (c *Client) createAndDoWithResponse(prefix, method, relPath string, data, options, resource interface{}) (*Response, error) {
+	return c.createAndDoContext(context.Background(), prefix, method, relPath, data, options, resource)
+}
+
+// createAndDoContext performs a web request to CircleCI with the context, so it can be canceled while in flight.
+func (c *Client) createAndDoContext(ctx context.Context, prefix, method, relPath string, data, options, resource interface{}) (*Response, error) {
 	if strings.HasPrefix(relPath, "/") {
 		// make sure it's a relative path
 		relPath = strings.TrimLeft(relPath, "/")
 	}
-	relPath = path.Join(c.pathPrefix, relPath)
+	relPath = path.Join(prefix, relPath)
 
 	req, err := c.NewRequest(method, relPath, data, options)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return c.do(req, resource)
+	return c.doWithResponse(req.WithContext(ctx), resource)
 }
 
-// doGetHeaders executes a request, decoding the response into `v` and also returns any response headers.
+// do executes a request with hooks, decoding the response into `v`.
 func (c *Client) do(req *http.Request, v interface{}) error {
-	var resp *http.Response
-	var err error
+	_, err := c.doWithResponse(req, v)
+	return err
+}
+
+// doWithResponse executes a request with hooks, decoding the response into `v`, and returns the Response.
+// GET requests to the API are served from and stored into the cache if the client has one.
+// The Response is recorded as the last response, and also set to *APIError on error responses.
+func (c *Client) doWithResponse(req *http.Request, v interface{}) (*Response, error) {
+	var key string
+	var cached *CacheEntry
+	if c.cacheable(req) {
+		key = cacheKey(req)
+		entry, fresh := c.cache.lookup(key)
+		if fresh {
+			return c.cacheHit(req, entry, v)
+		}
+		if entry != nil {
+			cached = entry
+			setConditionalHeaders(req, entry)
+		}
+	}
+
+	if err := c.waitRateLimit(req.Context()); err != nil {
+		return nil, err
+	}
+	for _, h := range c.hooks {
+		req = h.BeforeRequest(req)
+	}
+
+	start := time.Now()
+	resp, err := c.send(req)
+	if err == nil && resp.StatusCode == http.StatusNotModified && cached == nil {
+		// The response the request was conditional on is not in the cache, e.g. evicted, so request it in full.
+		resp.Body.Close()
+		req = withoutConditionalHeaders(req)
+		resp, err = c.send(req)
+	}
+	if c.cache != nil && req.Method != http.MethodGet && req.Method != http.MethodHead {
+		c.cache.invalidate(req)
+	}
+	if err == nil {
+		err = c.readBody(resp, key, cached, v)
+	}
+	elapsed := time.Since(start)
+
+	r := c.complete(req, resp, err, elapsed)
+	return r, err
+}
+
+// cacheable reports whether the request can be served from the cache, i.e. a GET request to an API of CircleCI.
+// Requests to other hosts, e.g. pre-signed URLs of step output, are never cached.
+func (c *Client) cacheable(req *http.Request) bool {
+	if c.cache == nil || req.Method != http.MethodGet {
+		return false
+	}
+	for _, u := range []*url.URL{c.BaseURL, c.runnerURL(), c.webhookURL()} {
+		if req.URL.Host == u.Host {
+			return true
+		}
+	}
+	return false
+}
 
-	resp, err = c.HTTPClient.Do(req)
+// cacheHit serves the request from the cache entry. Hooks observe it as a response of 200.
+func (c *Client) cacheHit(req *http.Request, entry *CacheEntry, v interface{}) (*Response, error) {
+	for _, h := range c.hooks {
+		req = h.BeforeRequest(req)
+	}
+	start := time.Now()
+	err := decodeBody(entry.Body, v)
+	elapsed := time.Since(start)
+
+	r := &Response{StatusCode: http.StatusOK, Header: http.Header{}, Cached: true}
+	c.setLastResponse(r)
+	for _, h := range c.hooks {
+		if err != nil {
+			h.OnError(req, err, elapsed)
+		} else {
+			h.AfterResponse(req, &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Request: req}, elapsed)
+		}
+	}
+	return r, err
+}
+
+// complete records the response and calls hooks after a request is done.
+func (c *Client) complete(req *http.Request, resp *http.Response, err error, elapsed time.Duration) *Response {
+	var r *Response
+	if resp != nil {
+		r = newResponse(resp)
+		c.setLastResponse(r)
+		c.observeRateLimit(r)
+		if apiErr, ok := err.(*APIError); ok {
+			apiErr.Response = r
+		}
+	}
+
+	for _, h := range c.hooks {
+		if err != nil {
+			h.OnError(req, err, elapsed)
+		} else {
+			h.AfterResponse(req, resp, elapsed)
+		}
+	}
+	return r
+}
+
+// send executes a request and returns the response with its body open to read.
+// Error responses are returned as *APIError with the body closed. 304 Not Modified is not regarded as an error.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if resp.StatusCode < 300 || resp.StatusCode == http.StatusNotModified {
+		return resp, nil
 	}
-	// retry scenario, close resp and any continue will retry
 	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := ioutil.ReadAll(c.limitBody(resp.Body, maxErrorBodySize))
 	if err != nil {
-		return &APIError{HTTPStatusCode: resp.StatusCode,
+		return resp, &APIError{HTTPStatusCode: resp.StatusCode,
 			Message: fmt.Sprintf("unable to read response body: %s", err),
 		}
 	}
-
-	if resp.StatusCode >= 300 {
-		if len(body) > 0 {
-			message := Message{}
-			err = json.Unmarshal(body, &message)
-			if err != nil {
-				return &APIError{
-					HTTPStatusCode: resp.StatusCode,
-					Message:        fmt.Sprintf("unable to parse API response: %s", err),
-				}
+	if len(body) > 0 {
+		message := Message{}
+		err = json.Unmarshal(body, &message)
+		if err != nil {
+			return resp, &APIError{
+				HTTPStatusCode: resp.StatusCode,
+				Message:        fmt.Sprintf("unable to parse API response: %s", err),
 			}
-			return &APIError{HTTPStatusCode: resp.StatusCode, Message: message.Message}
 		}
-
-		return &APIError{HTTPStatusCode: resp.StatusCode}
+		return resp, &APIError{HTTPStatusCode: resp.StatusCode, Message: message.Message}
 	}
 
-	if v != nil {
-		err = json.Unmarshal(body, v)
+	return resp, &APIError{HTTPStatusCode: resp.StatusCode}
+}
+
+// readBody decodes the body of the response into `v` and closes it.
+// The body is decoded as it is read from the connection unless it is stored into the cache.
+// 304 Not Modified is decoded from the cached entry, and is an error without it.
+func (c *Client) readBody(resp *http.Response, cacheKey string, cached *CacheEntry, v interface{}) error {
+	defer resp.Body.Close()
+	body := c.limitBody(resp.Body, c.maxBodySize)
+
+	if resp.StatusCode == http.StatusNotModified {
+		if cached == nil {
+			return fmt.Errorf("unexpected status %d without cached response", resp.StatusCode)
+		}
+		c.cache.revalidate(cacheKey, cached, resp)
+		return decodeBody(cached.Body, v)
+	}
+	if cacheKey != "" {
+		b, err := ioutil.ReadAll(body)
 		if err != nil {
 			return err
 		}
+		c.cache.store(cacheKey, resp, b)
+		return decodeBody(b, v)
+	}
+
+	if v == nil {
+		// Drain the body to reuse the connection.
+		_, err := io.Copy(ioutil.Discard, body)
+		return err
+	}
+	err := json.NewDecoder(body).Decode(v)
+	if err == io.EOF {
+		// Empty body
+		return nil
+	}
+	return err
+}
+
+func decodeBody(body []byte, v interface{}) error {
+	if v == nil || len(body) == 0 {
+		return nil
 	}
-	return nil
+	return json.Unmarshal(body, v)
 }
 
 // Get performs a GET request for the given path and saves the result in the
@@ -200,6 +430,22 @@ func (c *Client) Get(path string, resource, options interface{}) error {
 	return c.CreateAndDo("GET", path, nil, options, resource)
 }
 
+// getURL performs a GET request for the given absolute URL without the API token,
+// e.g. for pre-signed URLs, and saves the result in the given resource.
+func (c *Client) getURL(rawURL string, resource interface{}) error {
+	return c.getURLContext(context.Background(), rawURL, resource)
+}
+
+func (c *Client) getURLContext(ctx context.Context, rawURL string, resource interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("User-Agent", UserAgent)
+	return c.do(req, resource)
+}
+
 // Post performs a POST request for the given path and saves the result in the
 // given resource.
 func (c *Client) Post(path string, data, resource interface{}) error {
@@ -224,7 +470,13 @@ type Message struct {
 	Reason  string `json:"reason,omitempty"`
 }
 
+// PageOptions represents the page token given to endpoints returning paginated list.
+type PageOptions struct {
+	PageToken string `url:"page-token,omitempty"`
+}
+
 // ProjectSlug assemle ProjectSlug of CircleCI.
+// See Slug to parse, normalize or validate it.
 // projectType: bitbucket, github(gh)
 // org: organization name or user nme
 // repo: repository name
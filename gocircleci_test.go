@@ -1,8 +1,14 @@
 package circleci_test
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ttyfky/go-circleci"
 )
@@ -16,6 +22,49 @@ func TestNewClient(t *testing.T) {
 	}
 }
 
+// hostRecorder records the host of each request by the path, and responds with 404 Not Found.
+type hostRecorder map[string]string
+
+func (r hostRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	r[req.URL.Path] = req.URL.Host
+	return &http.Response{StatusCode: http.StatusNotFound, Body: ioutil.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+func TestNewClient_WithBaseURL(t *testing.T) {
+	example, _ := url.Parse("https://circleci.example.com")
+	circleciCom, _ := url.Parse("https://circleci.com")
+	hooks, _ := url.Parse("https://hooks.example.com")
+	tests := []struct {
+		name    string
+		opts    []circleci.Option
+		baseURL *url.URL
+		runner  string
+		webhook string
+	}{
+		{"default", nil, nil, "runner.circleci.com", "circleci.com"},
+		{"server", []circleci.Option{circleci.WithBaseURL(example)}, nil, "circleci.example.com", "circleci.example.com"},
+		{"circleci.com", []circleci.Option{circleci.WithBaseURL(circleciCom)}, nil, "runner.circleci.com", "circleci.com"},
+		{"BaseURL set later", nil, example, "circleci.example.com", "circleci.example.com"},
+		{"explicit", []circleci.Option{circleci.WithRunnerURL(hooks), circleci.WithWebhookURL(hooks)}, example, "hooks.example.com", "hooks.example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := circleci.NewClient("test_token", tt.opts...)
+			if tt.baseURL != nil {
+				c.BaseURL = tt.baseURL
+			}
+			hosts := hostRecorder{}
+			c.HTTPClient.Transport = hosts
+			if _, err := c.ProbeCapabilities(); err != nil {
+				t.Fatal(err)
+			}
+			if hosts["/api/v3/runner"] != tt.runner || hosts["/api/v2/webhook"] != tt.webhook {
+				t.Errorf("Invalid hosts. Expected: %s, %s, Actual: %v", tt.runner, tt.webhook, hosts)
+			}
+		})
+	}
+}
+
 func TestProjectSlug(t *testing.T) {
 	projectType := "gh"
 	org := "ttyfky"
@@ -26,3 +75,95 @@ func TestProjectSlug(t *testing.T) {
 		t.Errorf("Invalid ProjectSlug. Expected: %s, Actual:%s", expected, actual)
 	}
 }
+
+func TestClient_NewRequest_Authentication(t *testing.T) {
+	if _, err := circleci.NewClient("").NewRequest("GET", "/api/v2/me", nil, nil); err == nil {
+		t.Error("Expected an error for blank token")
+	}
+
+	req, err := circleci.NewAnonymousClient().NewRequest("GET", "/api/v2/me", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Header.Get("Circle-Token") != "" {
+		t.Errorf("Anonymous client must not set token. Actual: %s", req.Header.Get("Circle-Token"))
+	}
+
+	token := circleci.NewRotatingToken("old")
+	c := circleci.NewClient("", circleci.WithAuthenticator(&circleci.BasicAuth{Source: token}))
+	token.Rotate("new")
+	req, err = c.NewRequest("GET", "/api/v2/me", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user, _, ok := req.BasicAuth(); !ok || user != "new" {
+		t.Errorf("Invalid basic auth. Expected: new, Actual: %s", user)
+	}
+}
+
+func TestClient_Response(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/project/gh/ttyfky/go-circleci", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "99")
+		w.Header().Set("X-RateLimit-Reset", "30")
+		fmt.Fprint(w, `{"slug": "gh/ttyfky/go-circleci"}`)
+	})
+	mux.HandleFunc("/api/v2/workflow/wf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"message": "Too many requests"}`)
+	})
+	c := newTestClient(t, mux)
+
+	p := &circleci.Project{}
+	resp, err := c.CreateAndDoWithResponse("GET", "/project/gh/ttyfky/go-circleci", nil, nil, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RequestID != "req-1" || resp.RateLimit.Limit != 100 || resp.RateLimit.Remaining != 99 {
+		t.Errorf("Invalid response. Actual: %+v", resp)
+	}
+	if d := time.Until(resp.RateLimit.Reset); d <= 0 || d > 30*time.Second {
+		t.Errorf("Invalid reset. Actual: %s", resp.RateLimit.Reset)
+	}
+	if c.LastResponse() != resp {
+		t.Error("LastResponse must be the response of the last request")
+	}
+
+	_, err = c.Workflow.Get("wf")
+	var apiErr *circleci.APIError
+	if !errors.As(err, &apiErr) || apiErr.Response == nil {
+		t.Fatalf("Expected APIError with response. Actual: %v", err)
+	}
+	if apiErr.Response.RateLimit.RetryAfter != 10*time.Second {
+		t.Errorf("Invalid retry after. Actual: %s", apiErr.Response.RateLimit.RetryAfter)
+	}
+}
+
+func TestClient_MaxResponseBodySize(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/project/gh/ttyfky/go-circleci", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"slug": "gh/ttyfky/go-circleci"}`)
+	})
+	c := newTestClient(t, mux)
+	circleci.WithMaxResponseBodySize(10)(c)
+
+	if _, err := c.Project.Get("gh/ttyfky/go-circleci"); !errors.Is(err, circleci.ErrBodyTooLarge) {
+		t.Errorf("Expected ErrBodyTooLarge. Actual: %v", err)
+	}
+
+	body, resp, err := c.GetRaw("/project/gh/ttyfky/go-circleci", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer body.Close()
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || string(b) != `{"slug": "gh/ttyfky/go-circleci"}` {
+		t.Errorf("Invalid raw response. Status: %d, Body: %s", resp.StatusCode, b)
+	}
+}
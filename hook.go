@@ -0,0 +1,82 @@
+package circleci
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Hook observes requests made by Client.
+// BeforeRequest is called before sending a request and may return a request derived from it, e.g. with a new context.
+// Then exactly one of AfterResponse or OnError is called per request. OnError is called when the request failed
+// either in transport or with an error response, the latter of which is given as *APIError.
+type Hook interface {
+	BeforeRequest(req *http.Request) *http.Request
+	AfterResponse(req *http.Request, resp *http.Response, elapsed time.Duration)
+	OnError(req *http.Request, err error, elapsed time.Duration)
+}
+
+// HookFuncs is an adapter to make Hook from functions. Nil functions are ignored.
+type HookFuncs struct {
+	Before func(req *http.Request) *http.Request
+	After  func(req *http.Request, resp *http.Response, elapsed time.Duration)
+	Error  func(req *http.Request, err error, elapsed time.Duration)
+}
+
+var _ Hook = (*HookFuncs)(nil)
+
+// BeforeRequest calls Before if given.
+func (h *HookFuncs) BeforeRequest(req *http.Request) *http.Request {
+	if h.Before == nil {
+		return req
+	}
+	return h.Before(req)
+}
+
+// AfterResponse calls After if given.
+func (h *HookFuncs) AfterResponse(req *http.Request, resp *http.Response, elapsed time.Duration) {
+	if h.After != nil {
+		h.After(req, resp, elapsed)
+	}
+}
+
+// OnError calls Error if given.
+func (h *HookFuncs) OnError(req *http.Request, err error, elapsed time.Duration) {
+	if h.Error != nil {
+		h.Error(req, err, elapsed)
+	}
+}
+
+// slugSegmentLen is the number of path segments in a project slug, e.g. gh/org/repo.
+const slugSegmentLen = 3
+
+var (
+	uuidPattern   = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	numberPattern = regexp.MustCompile(`^[0-9]+$`)
+	namedSegments = map[string]string{"envvar": "{name}", "environment-variable": "{name}", "approve": "{id}", "tree": "{branch}"}
+)
+
+// Endpoint returns the path of the request with its IDs, project slug and names replaced by placeholders,
+// e.g. /api/v2/project/{project-slug}/job/{number}. This can be used as a label with low cardinality.
+func Endpoint(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	out := make([]string, 0, len(segments))
+	for i := 0; i < len(segments); i++ {
+		seg := segments[i]
+		switch {
+		case i > 0 && segments[i-1] == "project" && len(segments)-i >= slugSegmentLen:
+			out = append(out, "{project-slug}")
+			i += slugSegmentLen - 1
+			continue
+		case i > 0 && namedSegments[segments[i-1]] != "":
+			seg = namedSegments[segments[i-1]]
+		case uuidPattern.MatchString(seg):
+			seg = "{id}"
+		case numberPattern.MatchString(seg):
+			seg = "{number}"
+		}
+		out = append(out, seg)
+	}
+	return "/" + strings.Join(out, "/")
+}
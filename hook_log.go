@@ -0,0 +1,121 @@
+package circleci
+
+import (
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// sensitiveHeaders are the headers whose values are never logged.
+var sensitiveHeaders = map[string]bool{
+	"Circle-Token":  true,
+	"Authorization": true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+}
+
+// LoggingHook is a Hook which logs every request in key=value format with credentials redacted.
+type LoggingHook struct {
+	// Logger to write to (defaults to the logger writing to stderr)
+	Logger *log.Logger
+	// Headers makes request headers logged
+	Headers bool
+	// ErrorsOnly makes only failed requests logged
+	ErrorsOnly bool
+}
+
+var _ Hook = (*LoggingHook)(nil)
+
+// NewLoggingHook creates LoggingHook writing to the logger. The default logger is used if nil.
+func NewLoggingHook(logger *log.Logger) *LoggingHook {
+	return &LoggingHook{Logger: logger}
+}
+
+func (h *LoggingHook) logger() *log.Logger {
+	if h.Logger == nil {
+		return defaultLogger
+	}
+	return h.Logger
+}
+
+// BeforeRequest does nothing.
+func (h *LoggingHook) BeforeRequest(req *http.Request) *http.Request {
+	return req
+}
+
+// AfterResponse logs the succeeded request.
+func (h *LoggingHook) AfterResponse(req *http.Request, resp *http.Response, elapsed time.Duration) {
+	if h.ErrorsOnly {
+		return
+	}
+	h.logger().Printf("%s status=%d", h.fields(req, elapsed), resp.StatusCode)
+}
+
+// OnError logs the failed request.
+func (h *LoggingHook) OnError(req *http.Request, err error, elapsed time.Duration) {
+	status := 0
+	if apiErr, ok := err.(*APIError); ok {
+		status = apiErr.HTTPStatusCode
+	}
+	h.logger().Printf("%s status=%d error=%q", h.fields(req, elapsed), status, err.Error())
+}
+
+func (h *LoggingHook) fields(req *http.Request, elapsed time.Duration) string {
+	var b strings.Builder
+	b.WriteString("method=" + req.Method)
+	b.WriteString(" endpoint=" + Endpoint(req))
+	b.WriteString(" url=" + RedactURL(req.URL))
+	b.WriteString(" duration=" + elapsed.Round(time.Millisecond).String())
+	if h.Headers {
+		names := make([]string, 0, len(req.Header))
+		for name := range req.Header {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			b.WriteString(" header." + strings.ToLower(name) + "=" + strings.Join(RedactHeader(name, req.Header[name]), ","))
+		}
+	}
+	return b.String()
+}
+
+// RedactHeader returns values of the header with credentials redacted.
+func RedactHeader(name string, values []string) []string {
+	if !sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+		return values
+	}
+	out := make([]string, len(values))
+	for i := range values {
+		out[i] = redacted
+	}
+	return out
+}
+
+// RedactURL returns the URL in string with credentials in user info and query parameters redacted.
+func RedactURL(u *url.URL) string {
+	copied := *u
+	if copied.User != nil {
+		copied.User = url.User(redacted)
+	}
+	q := copied.Query()
+	changed := false
+	for k := range q {
+		lk := strings.ToLower(k)
+		if lk == "page-token" {
+			continue
+		}
+		if strings.Contains(lk, "token") || strings.Contains(lk, "secret") || strings.Contains(lk, "signature") {
+			q.Set(k, redacted)
+			changed = true
+		}
+	}
+	if changed {
+		copied.RawQuery = q.Encode()
+	}
+	return copied.String()
+}
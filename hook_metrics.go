@@ -0,0 +1,190 @@
+package circleci
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDurationBuckets are the upper bounds in seconds of the buckets of request duration histogram.
+var DefaultDurationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20}
+
+// MetricsHook is a Hook which counts requests and observes their duration per method, endpoint and status,
+// and exposes them in Prometheus text format.
+type MetricsHook struct {
+	// Namespace is the prefix of metric names (defaults to circleci)
+	Namespace string
+	// Buckets are the upper bounds of duration histogram (defaults to DefaultDurationBuckets)
+	Buckets []float64
+
+	mu     sync.Mutex
+	series map[metricLabels]*metricSeries
+}
+
+var _ Hook = (*MetricsHook)(nil)
+
+type metricLabels struct {
+	Method   string
+	Endpoint string
+	Status   string
+}
+
+type metricSeries struct {
+	count   uint64
+	sum     float64
+	buckets []uint64
+}
+
+// RequestMetric represents metrics of requests with the same method, endpoint and status.
+type RequestMetric struct {
+	Method   string
+	Endpoint string
+	Status   string
+	Count    uint64
+	Duration time.Duration
+}
+
+// NewMetricsHook creates MetricsHook.
+func NewMetricsHook() *MetricsHook {
+	return &MetricsHook{}
+}
+
+// BeforeRequest does nothing.
+func (h *MetricsHook) BeforeRequest(req *http.Request) *http.Request {
+	return req
+}
+
+// AfterResponse records the succeeded request.
+func (h *MetricsHook) AfterResponse(req *http.Request, resp *http.Response, elapsed time.Duration) {
+	h.observe(req, strconv.Itoa(resp.StatusCode), elapsed)
+}
+
+// OnError records the failed request. Status is "error" when no response was received.
+func (h *MetricsHook) OnError(req *http.Request, err error, elapsed time.Duration) {
+	status := "error"
+	if apiErr, ok := err.(*APIError); ok && apiErr.HTTPStatusCode != 0 {
+		status = strconv.Itoa(apiErr.HTTPStatusCode)
+	}
+	h.observe(req, status, elapsed)
+}
+
+func (h *MetricsHook) buckets() []float64 {
+	if len(h.Buckets) == 0 {
+		return DefaultDurationBuckets
+	}
+	return h.Buckets
+}
+
+func (h *MetricsHook) observe(req *http.Request, status string, elapsed time.Duration) {
+	l := metricLabels{Method: req.Method, Endpoint: Endpoint(req), Status: status}
+	seconds := elapsed.Seconds()
+	buckets := h.buckets()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.series == nil {
+		h.series = map[metricLabels]*metricSeries{}
+	}
+	s, ok := h.series[l]
+	if !ok {
+		s = &metricSeries{buckets: make([]uint64, len(buckets))}
+		h.series[l] = s
+	}
+	s.count++
+	s.sum += seconds
+	for i, le := range buckets {
+		if seconds <= le {
+			s.buckets[i]++
+		}
+	}
+}
+
+func (h *MetricsHook) sortedLabels() []metricLabels {
+	labels := make([]metricLabels, 0, len(h.series))
+	for l := range h.series {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		a, b := labels[i], labels[j]
+		if a.Endpoint != b.Endpoint {
+			return a.Endpoint < b.Endpoint
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Status < b.Status
+	})
+	return labels
+}
+
+// Metrics returns the recorded metrics ordered by endpoint, method and status.
+func (h *MetricsHook) Metrics() []RequestMetric {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var metrics []RequestMetric
+	for _, l := range h.sortedLabels() {
+		s := h.series[l]
+		metrics = append(metrics, RequestMetric{
+			Method:   l.Method,
+			Endpoint: l.Endpoint,
+			Status:   l.Status,
+			Count:    s.count,
+			Duration: time.Duration(s.sum * float64(time.Second)),
+		})
+	}
+	return metrics
+}
+
+// WritePrometheus writes the recorded metrics to w in Prometheus text exposition format.
+func (h *MetricsHook) WritePrometheus(w io.Writer) error {
+	ns := h.Namespace
+	if ns == "" {
+		ns = "circleci"
+	}
+	buckets := h.buckets()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var b strings.Builder
+	labels := h.sortedLabels()
+
+	fmt.Fprintf(&b, "# HELP %s_requests_total Number of requests to CircleCI API.\n", ns)
+	fmt.Fprintf(&b, "# TYPE %s_requests_total counter\n", ns)
+	for _, l := range labels {
+		fmt.Fprintf(&b, "%s_requests_total{%s} %d\n", ns, l.format(""), h.series[l].count)
+	}
+
+	fmt.Fprintf(&b, "# HELP %s_request_duration_seconds Duration of requests to CircleCI API.\n", ns)
+	fmt.Fprintf(&b, "# TYPE %s_request_duration_seconds histogram\n", ns)
+	for _, l := range labels {
+		s := h.series[l]
+		for i, le := range buckets {
+			fmt.Fprintf(&b, "%s_request_duration_seconds_bucket{%s} %d\n", ns, l.format(strconv.FormatFloat(le, 'g', -1, 64)), s.buckets[i])
+		}
+		fmt.Fprintf(&b, "%s_request_duration_seconds_bucket{%s} %d\n", ns, l.format("+Inf"), s.count)
+		fmt.Fprintf(&b, "%s_request_duration_seconds_sum{%s} %g\n", ns, l.format(""), s.sum)
+		fmt.Fprintf(&b, "%s_request_duration_seconds_count{%s} %d\n", ns, l.format(""), s.count)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ServeHTTP serves the metrics in Prometheus text exposition format, so MetricsHook can be a scrape target.
+func (h *MetricsHook) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	_ = h.WritePrometheus(w)
+}
+
+func (l metricLabels) format(le string) string {
+	s := fmt.Sprintf("method=%q,endpoint=%q,status=%q", l.Method, l.Endpoint, l.Status)
+	if le != "" {
+		s += fmt.Sprintf(",le=%q", le)
+	}
+	return s
+}
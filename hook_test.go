@@ -0,0 +1,74 @@
+package circleci_test
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+func TestEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"/api/v2/project/gh/ttyfky/go-circleci/job/123":                     "/api/v2/project/{project-slug}/job/{number}",
+		"/api/v2/project/gh/ttyfky/go-circleci/envvar/TOKEN":                "/api/v2/project/{project-slug}/envvar/{name}",
+		"/api/v2/workflow/5034460f-c7c4-4c43-9457-de07e2029e7b/approve/abc": "/api/v2/workflow/{id}/approve/{id}",
+		"/api/v1.1/project/github/ttyfky/go-circleci/tree/main":             "/api/v1.1/project/{project-slug}/tree/{branch}",
+	}
+	for path, expected := range tests {
+		req, _ := http.NewRequest("GET", "https://circleci.com"+path, nil)
+		if actual := circleci.Endpoint(req); actual != expected {
+			t.Errorf("Invalid endpoint of %s. Expected: %s, Actual: %s", path, expected, actual)
+		}
+	}
+}
+
+func TestHooks(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/project/gh/ttyfky/go-circleci", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"slug": "gh/ttyfky/go-circleci"}`)
+	})
+	mux.HandleFunc("/api/v2/workflow/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"message": "Workflow not found"}`)
+	})
+
+	var logs bytes.Buffer
+	logging := &circleci.LoggingHook{Logger: log.New(&logs, "", 0), Headers: true}
+	metrics := circleci.NewMetricsHook()
+	c := newTestClient(t, mux)
+	circleci.WithHook(logging)(c)
+	circleci.WithHook(metrics)(c)
+
+	if _, err := c.Project.Get("gh/ttyfky/go-circleci"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Workflow.Get("123"); err == nil {
+		t.Fatal("Expected an error for unknown workflow")
+	}
+
+	out := logs.String()
+	if strings.Contains(out, "test_token") || !strings.Contains(out, "header.circle-token=REDACTED") {
+		t.Errorf("Token must be redacted. Actual:\n%s", out)
+	}
+	if !strings.Contains(out, `status=404 error="404: Workflow not found"`) {
+		t.Errorf("Error must be logged. Actual:\n%s", out)
+	}
+
+	var prom bytes.Buffer
+	if err := metrics.WritePrometheus(&prom); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`circleci_requests_total{method="GET",endpoint="/api/v2/project/{project-slug}",status="200"} 1`,
+		`circleci_requests_total{method="GET",endpoint="/api/v2/workflow/{number}",status="404"} 1`,
+		`circleci_request_duration_seconds_count{method="GET",endpoint="/api/v2/workflow/{number}",status="404"} 1`,
+	} {
+		if !strings.Contains(prom.String(), expected) {
+			t.Errorf("Metrics do not contain %s. Actual:\n%s", expected, prom.String())
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package circleci
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Span is a unit of tracing of an API call.
+// It is a subset of trace.Span of OpenTelemetry so that it can be implemented by a thin wrapper.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts Span. It can be implemented by wrapping trace.Tracer of OpenTelemetry.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// TracingHook is a Hook which makes a Span per API call with attributes following
+// the semantic conventions of OpenTelemetry for HTTP clients.
+type TracingHook struct {
+	Tracer Tracer
+	// Propagate optionally injects the trace context into the request headers,
+	// e.g. with TextMapPropagator of OpenTelemetry.
+	Propagate func(ctx context.Context, header http.Header)
+}
+
+var _ Hook = (*TracingHook)(nil)
+
+type spanKey struct{}
+
+// NewTracingHook creates TracingHook with the tracer.
+func NewTracingHook(tracer Tracer) *TracingHook {
+	return &TracingHook{Tracer: tracer}
+}
+
+// BeforeRequest starts a span and stores it in the context of the request.
+func (h *TracingHook) BeforeRequest(req *http.Request) *http.Request {
+	endpoint := Endpoint(req)
+	ctx, span := h.Tracer.Start(req.Context(), "CircleCI "+req.Method+" "+endpoint)
+	span.SetAttribute("http.method", req.Method)
+	span.SetAttribute("http.url", RedactURL(req.URL))
+	span.SetAttribute("http.route", endpoint)
+	span.SetAttribute("peer.service", "circleci")
+
+	req = req.WithContext(context.WithValue(ctx, spanKey{}, span))
+	if h.Propagate != nil {
+		h.Propagate(req.Context(), req.Header)
+	}
+	return req
+}
+
+// AfterResponse ends the span with the status code.
+func (h *TracingHook) AfterResponse(req *http.Request, resp *http.Response, _ time.Duration) {
+	span, ok := req.Context().Value(spanKey{}).(Span)
+	if !ok {
+		return
+	}
+	span.SetAttribute("http.status_code", resp.StatusCode)
+	span.End()
+}
+
+// OnError ends the span with the error.
+func (h *TracingHook) OnError(req *http.Request, err error, _ time.Duration) {
+	span, ok := req.Context().Value(spanKey{}).(Span)
+	if !ok {
+		return
+	}
+	if apiErr, ok := err.(*APIError); ok && apiErr.HTTPStatusCode != 0 {
+		span.SetAttribute("http.status_code", apiErr.HTTPStatusCode)
+	}
+	span.RecordError(err)
+	span.End()
+}
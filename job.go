@@ -1,6 +1,9 @@
 package circleci
 
-import "time"
+import (
+	"io"
+	"time"
+)
 
 const jobBasePath = "/job"
 
@@ -9,6 +12,8 @@ type JobService interface {
 	Cancel(id, projectSlug string) (*Message, error)
 	GetArtifacts(id, projectSlug string) (*ArtifactList, error)
 	GetTestMetadata(id, projectSlug string) (*TestMetadataList, error)
+	ListTestMetadata(id, projectSlug string) ([]Metadata, error)
+	DownloadArtifact(artifact *Artifact) (io.ReadCloser, error)
 }
 
 // JobOp handles communication with the project related methods in the CircleCI API v2.
@@ -72,13 +77,13 @@ type Jobs struct {
 
 // Metadata represents information about a test metadata of a Job.
 type Metadata struct {
-	Message   string `json:"message,omitempty"`
-	Source    string `json:"source,omitempty"`
-	RunTime   string `json:"run_time,omitempty"`
-	File      string `json:"file,omitempty"`
-	Result    string `json:"result,omitempty"`
-	Name      string `json:"name,omitempty"`
-	Classname string `json:"classname,omitempty"`
+	Message   string  `json:"message,omitempty"`
+	Source    string  `json:"source,omitempty"`
+	RunTime   float64 `json:"run_time,omitempty"`
+	File      string  `json:"file,omitempty"`
+	Result    string  `json:"result,omitempty"`
+	Name      string  `json:"name,omitempty"`
+	Classname string  `json:"classname,omitempty"`
 }
 
 // TestMetadataList contains list of Metadata of test.
@@ -126,7 +131,7 @@ func (ps *JobOp) Cancel(id, projectSlug string) (*Message, error) {
 func (ps *JobOp) GetArtifacts(id, projectSlug string) (*ArtifactList, error) {
 	al := &ArtifactList{}
 	path := jobIDPath(id, projectSlug) + "/artifacts"
-	err := ps.client.Get(path, nil, al)
+	err := ps.client.Get(path, al, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -137,13 +142,38 @@ func (ps *JobOp) GetArtifacts(id, projectSlug string) (*ArtifactList, error) {
 func (ps *JobOp) GetTestMetadata(id, projectSlug string) (*TestMetadataList, error) {
 	tml := &TestMetadataList{}
 	path := jobIDPath(id, projectSlug) + "/tests"
-	err := ps.client.Get(path, nil, tml)
+	err := ps.client.Get(path, tml, nil)
 	if err != nil {
 		return nil, err
 	}
 	return tml, nil
 }
 
+// ListTestMetadata gets metadata of all tests in a job by following every page.
+func (ps *JobOp) ListTestMetadata(id, projectSlug string) ([]Metadata, error) {
+	var items []Metadata
+	path := jobIDPath(id, projectSlug) + "/tests"
+	opts := &PageOptions{}
+	for {
+		tml := &TestMetadataList{}
+		err := ps.client.Get(path, tml, opts)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, tml.Items...)
+		if tml.NextPageToken == "" {
+			return items, nil
+		}
+		opts.PageToken = tml.NextPageToken
+	}
+}
+
+// DownloadArtifact downloads the content of the artifact.
+// The caller must close the returned body.
+func (ps *JobOp) DownloadArtifact(artifact *Artifact) (io.ReadCloser, error) {
+	return ps.client.openURL(artifact.URL, true)
+}
+
 func jobIDPath(id, projectSlug string) string {
 	return projectPathPrefix(projectSlug) + jobBasePath + "/" + id
 }
@@ -0,0 +1,190 @@
+package circleci
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultTailInterval = 3 * time.Second
+
+	lifecycleFinished = "finished"
+)
+
+// JobLogService is an interface for step output of jobs, which is only available in API v1.1.
+type JobLogService interface {
+	Output(action *StepAction) ([]OutputMessage, error)
+	OutputReader(action *StepAction) (io.ReadCloser, error)
+	Tail(ctx context.Context, projectSlug string, jobNumber, node int, w io.Writer) error
+}
+
+// JobLogOp handles communication with the build related methods in the CircleCI API v1.1.
+type JobLogOp struct {
+	client *Client
+}
+
+var _ JobLogService = (*JobLogOp)(nil)
+
+// BuildDetail represents a build, i.e. a job, with its steps in API v1.1.
+type BuildDetail struct {
+	BuildSummary
+	Parallel int     `json:"parallel,omitempty"`
+	Steps    []*Step `json:"steps,omitempty"`
+}
+
+// Step represents a step of a job. A step has one action per parallel node.
+type Step struct {
+	Name    string        `json:"name,omitempty"`
+	Actions []*StepAction `json:"actions,omitempty"`
+}
+
+// StepAction represents a step run on a parallel node.
+type StepAction struct {
+	Name          string    `json:"name,omitempty"`
+	Type          string    `json:"type,omitempty"`
+	Index         int       `json:"index"`
+	Step          int       `json:"step"`
+	Status        string    `json:"status,omitempty"`
+	Failed        bool      `json:"failed,omitempty"`
+	ExitCode      *int      `json:"exit_code,omitempty"`
+	StartTime     time.Time `json:"start_time,omitempty"`
+	EndTime       time.Time `json:"end_time,omitempty"`
+	RunTimeMillis int64     `json:"run_time_millis,omitempty"`
+	HasOutput     bool      `json:"has_output,omitempty"`
+	OutputURL     string    `json:"output_url,omitempty"`
+	Background    bool      `json:"background,omitempty"`
+	BashCommand   string    `json:"bash_command,omitempty"`
+}
+
+// OutputMessage represents a chunk of output of a step.
+type OutputMessage struct {
+	Type      string    `json:"type,omitempty"`
+	Time      time.Time `json:"time,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Truncated bool      `json:"truncated,omitempty"`
+}
+
+// IsFinished reports whether the job has finished.
+func (b *BuildDetail) IsFinished() bool {
+	return b.Lifecycle == lifecycleFinished
+}
+
+// NodeActions returns the actions run on the parallel node of the given index, in the order of steps.
+func (b *BuildDetail) NodeActions(node int) []*StepAction {
+	var actions []*StepAction
+	for _, s := range b.Steps {
+		for _, a := range s.Actions {
+			if a.Index == node {
+				actions = append(actions, a)
+			}
+		}
+	}
+	return actions
+}
+
+// Output downloads the output of a finished step.
+func (ps *JobLogOp) Output(action *StepAction) ([]OutputMessage, error) {
+	return ps.output(context.Background(), action)
+}
+
+func (ps *JobLogOp) output(ctx context.Context, action *StepAction) ([]OutputMessage, error) {
+	if !action.HasOutput || action.OutputURL == "" {
+		return nil, nil
+	}
+	var out []OutputMessage
+	err := ps.client.getURLContext(ctx, action.OutputURL, &out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// OutputReader opens the output of a finished step in JSON without decoding, for outputs too large to decode at once.
+// The caller must close the returned body.
+func (ps *JobLogOp) OutputReader(action *StepAction) (io.ReadCloser, error) {
+	if action.OutputURL == "" {
+		return nil, fmt.Errorf("step %q has no output URL", action.Name)
+	}
+	return ps.client.openURL(action.OutputURL, false)
+}
+
+// liveOutput gets the output of a step produced so far, which is available even while it is running.
+func (ps *JobLogOp) liveOutput(ctx context.Context, projectSlug string, jobNumber int, action *StepAction) ([]OutputMessage, error) {
+	var out []OutputMessage
+	path := v1BuildPath(projectSlug, jobNumber) + "/output/" + strconv.Itoa(action.Step) + "/" + strconv.Itoa(action.Index)
+	_, err := ps.client.createAndDoContext(ctx, ps.client.v1PathPrefix, "GET", path, nil, nil, &out)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Tail writes the output of the steps run on the parallel node to w as the job proceeds.
+// It polls the job until the job finishes or ctx is done, which also cancels the request in flight.
+func (ps *JobLogOp) Tail(ctx context.Context, projectSlug string, jobNumber, node int, w io.Writer) error {
+	// written holds how many bytes of output of each step are already written.
+	written := map[int]int{}
+	started := map[int]bool{}
+	done := map[int]bool{}
+	for {
+		b := &BuildDetail{}
+		_, err := ps.client.createAndDoContext(ctx, ps.client.v1PathPrefix, "GET", v1BuildPath(projectSlug, jobNumber), nil, nil, b)
+		if err != nil {
+			return err
+		}
+
+		for _, a := range b.NodeActions(node) {
+			if done[a.Step] {
+				continue
+			}
+			if !started[a.Step] {
+				if _, err := fmt.Fprintf(w, "==> %s\n", a.Name); err != nil {
+					return err
+				}
+				started[a.Step] = true
+			}
+
+			finished := a.Status != "" && a.Status != "running"
+			var out []OutputMessage
+			switch {
+			case finished && a.OutputURL != "":
+				out, err = ps.output(ctx, a)
+			case a.HasOutput || !finished:
+				out, err = ps.liveOutput(ctx, projectSlug, jobNumber, a)
+			}
+			if err != nil {
+				return err
+			}
+
+			text := joinOutput(out)
+			if len(text) > written[a.Step] {
+				if _, err := io.WriteString(w, text[written[a.Step]:]); err != nil {
+					return err
+				}
+				written[a.Step] = len(text)
+			}
+			done[a.Step] = finished
+		}
+
+		if b.IsFinished() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(defaultTailInterval):
+		}
+	}
+}
+
+func joinOutput(out []OutputMessage) string {
+	var b strings.Builder
+	for _, m := range out {
+		b.WriteString(m.Message)
+	}
+	return b.String()
+}
@@ -0,0 +1,121 @@
+package circleci_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+func TestJobLogOp_Output(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/output/0", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Circle-Token") != "" {
+			t.Error("Pre-signed URL must not be requested with the token")
+		}
+		fmt.Fprint(w, `[{"type": "out", "message": "hello\n"}, {"type": "out", "message": "world\n"}]`)
+	})
+	c := newTestClient(t, mux)
+
+	out, err := c.JobLog.Output(&circleci.StepAction{HasOutput: true, OutputURL: c.BaseURL.String() + "/output/0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 || out[1].Message != "world\n" {
+		t.Errorf("Invalid output. Actual: %+v", out)
+	}
+
+	out, err = c.JobLog.Output(&circleci.StepAction{})
+	if err != nil || out != nil {
+		t.Errorf("Step without output must have no output. Actual: %+v, %v", out, err)
+	}
+}
+
+func TestBuildDetail_NodeActions(t *testing.T) {
+	b := &circleci.BuildDetail{Steps: []*circleci.Step{
+		{Name: "checkout", Actions: []*circleci.StepAction{{Name: "checkout", Index: 0}, {Name: "checkout", Index: 1}}},
+		{Name: "test", Actions: []*circleci.StepAction{{Name: "test", Index: 0, Step: 1}, {Name: "test", Index: 1, Step: 1}}},
+	}}
+	actions := b.NodeActions(1)
+	if len(actions) != 2 || actions[0].Index != 1 || actions[1].Index != 1 || actions[1].Name != "test" {
+		t.Errorf("Invalid actions of node 1. Actual: %+v", actions)
+	}
+	if actions := b.NodeActions(2); len(actions) != 0 {
+		t.Errorf("Node 2 must have no actions. Actual: %+v", actions)
+	}
+}
+
+func TestJobLogOp_Tail(t *testing.T) {
+	var server string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1.1/project/github/org/repo/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"lifecycle": "finished", "steps": [
+			{"name": "test", "actions": [
+				{"name": "test", "index": 0, "status": "success", "has_output": true, "output_url": "%[1]s/output/0"},
+				{"name": "test", "index": 1, "status": "success", "has_output": true, "output_url": "%[1]s/output/1"}]}]}`, server)
+	})
+	for _, node := range []int{0, 1} {
+		node := node
+		mux.HandleFunc(fmt.Sprintf("/output/%d", node), func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `[{"message": "node %d\n"}]`, node)
+		})
+	}
+	c := newTestClient(t, mux)
+	server = c.BaseURL.String()
+
+	var buf bytes.Buffer
+	if err := c.JobLog.Tail(context.Background(), "gh/org/repo", 1, 1, &buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "==> test\nnode 1\n"
+	if buf.String() != expected {
+		t.Errorf("Invalid output.\nExpected: %q\nActual:   %q", expected, buf.String())
+	}
+}
+
+func TestJobLogOp_Tail_Canceled(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1.1/project/github/org/repo/1", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"lifecycle": "running", "steps": [{"name": "test", "actions": [{"name": "test", "index": 0, "status": "running"}]}]}`)
+	})
+	mux.HandleFunc("/api/v1.1/project/github/org/repo/1/output/0/0", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"message": "running\n"}]`)
+	})
+	// The job of number 2 never responds.
+	mux.HandleFunc("/api/v1.1/project/github/org/repo/2", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	c := newTestClient(t, mux)
+
+	tests := []struct {
+		name      string
+		jobNumber int
+		output    string
+	}{
+		{"between polls", 1, "==> test\nrunning\n"},
+		{"in flight", 2, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			var buf bytes.Buffer
+			start := time.Now()
+			err := c.JobLog.Tail(ctx, "gh/org/repo", tt.jobNumber, 0, &buf)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected the deadline exceeded. Actual: %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Tail must return as soon as ctx is done. Actual: %s", elapsed)
+			}
+			if buf.String() != tt.output {
+				t.Errorf("Invalid output. Actual: %q", buf.String())
+			}
+		})
+	}
+}
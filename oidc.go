@@ -0,0 +1,359 @@
+package circleci
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	orgBasePath = "/org"
+
+	oidcIssuerPrefix = "https://oidc.circleci.com/org/"
+	// minRSAKeyBits is the minimum size of RSA keys to verify OIDC tokens with.
+	minRSAKeyBits = 2048
+)
+
+// Custom claims of OIDC tokens, given to OIDCService.DeleteOrgClaims and DeleteProjectClaims.
+const (
+	OIDCClaimAudience = "audience"
+	OIDCClaimTTL      = "ttl"
+)
+
+// ErrOIDCTokenExpired is returned when an OIDC token is expired. See ValidateOIDCToken.
+var ErrOIDCTokenExpired = errors.New("oidc token is expired")
+
+// OIDCService is an interface for custom claims of OIDC tokens in OIDC Token Management API.
+type OIDCService interface {
+	GetOrgClaims(orgID string) (*OIDCClaims, error)
+	SetOrgClaims(orgID string, update *OIDCClaimsUpdate) (*OIDCClaims, error)
+	DeleteOrgClaims(orgID string, claims ...string) (*OIDCClaims, error)
+	GetProjectClaims(orgID, projectID string) (*OIDCClaims, error)
+	SetProjectClaims(orgID, projectID string, update *OIDCClaimsUpdate) (*OIDCClaims, error)
+	DeleteProjectClaims(orgID, projectID string, claims ...string) (*OIDCClaims, error)
+}
+
+// OIDCOp handles communication with the OIDC token related methods in the CircleCI API v2.
+type OIDCOp struct {
+	client *Client
+}
+
+var _ OIDCService = (*OIDCOp)(nil)
+
+// OIDCClaims represents custom claims of OIDC tokens issued for an organization or a project.
+// ProjectID is empty for claims of an organization.
+type OIDCClaims struct {
+	OrgID             string    `json:"org_id,omitempty"`
+	ProjectID         string    `json:"project_id,omitempty"`
+	Audience          []string  `json:"audience,omitempty"`
+	AudienceUpdatedAt time.Time `json:"audience_updated_at,omitempty"`
+	TTL               string    `json:"ttl,omitempty"`
+	TTLUpdatedAt      time.Time `json:"ttl_updated_at,omitempty"`
+}
+
+// OIDCClaimsUpdate represents payload to set custom claims. Empty claims are kept as they are.
+// TTL is a duration such as 1h or 30m.
+type OIDCClaimsUpdate struct {
+	Audience []string `json:"audience,omitempty"`
+	TTL      string   `json:"ttl,omitempty"`
+}
+
+type oidcClaimsDeleteOptions struct {
+	Claims string `url:"claims"`
+}
+
+// GetOrgClaims gets custom claims of the organization.
+func (ps *OIDCOp) GetOrgClaims(orgID string) (*OIDCClaims, error) {
+	return ps.get(oidcOrgClaimsPath(orgID))
+}
+
+// SetOrgClaims sets custom claims of the organization.
+func (ps *OIDCOp) SetOrgClaims(orgID string, update *OIDCClaimsUpdate) (*OIDCClaims, error) {
+	return ps.set(oidcOrgClaimsPath(orgID), update)
+}
+
+// DeleteOrgClaims deletes custom claims of the organization, e.g. OIDCClaimAudience.
+func (ps *OIDCOp) DeleteOrgClaims(orgID string, claims ...string) (*OIDCClaims, error) {
+	return ps.delete(oidcOrgClaimsPath(orgID), claims)
+}
+
+// GetProjectClaims gets custom claims of the project, which override the ones of the organization.
+func (ps *OIDCOp) GetProjectClaims(orgID, projectID string) (*OIDCClaims, error) {
+	return ps.get(oidcProjectClaimsPath(orgID, projectID))
+}
+
+// SetProjectClaims sets custom claims of the project.
+func (ps *OIDCOp) SetProjectClaims(orgID, projectID string, update *OIDCClaimsUpdate) (*OIDCClaims, error) {
+	return ps.set(oidcProjectClaimsPath(orgID, projectID), update)
+}
+
+// DeleteProjectClaims deletes custom claims of the project, e.g. OIDCClaimTTL.
+func (ps *OIDCOp) DeleteProjectClaims(orgID, projectID string, claims ...string) (*OIDCClaims, error) {
+	return ps.delete(oidcProjectClaimsPath(orgID, projectID), claims)
+}
+
+func (ps *OIDCOp) get(path string) (*OIDCClaims, error) {
+	cl := &OIDCClaims{}
+	err := ps.client.Get(path, cl, nil)
+	if err != nil {
+		return nil, err
+	}
+	return cl, nil
+}
+
+func (ps *OIDCOp) set(path string, update *OIDCClaimsUpdate) (*OIDCClaims, error) {
+	cl := &OIDCClaims{}
+	err := ps.client.CreateAndDo("PATCH", path, update, nil, cl)
+	if err != nil {
+		return nil, err
+	}
+	return cl, nil
+}
+
+func (ps *OIDCOp) delete(path string, claims []string) (*OIDCClaims, error) {
+	if len(claims) == 0 {
+		return nil, errors.New("no claim to delete")
+	}
+	cl := &OIDCClaims{}
+	err := ps.client.CreateAndDo("DELETE", path, nil, &oidcClaimsDeleteOptions{Claims: strings.Join(claims, ",")}, cl)
+	if err != nil {
+		return nil, err
+	}
+	return cl, nil
+}
+
+func oidcOrgClaimsPath(orgID string) string {
+	return orgBasePath + "/" + orgID + "/oidc-custom-claims"
+}
+
+func oidcProjectClaimsPath(orgID, projectID string) string {
+	return orgBasePath + "/" + orgID + "/project/" + projectID + "/oidc-custom-claims"
+}
+
+// JWKS represents a JSON Web Key Set, e.g. the one served at https://oidc.circleci.com/org/<org-id>/.well-known/jwks-pub.json.
+type JWKS struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// JWK represents a JSON Web Key. Only RSA keys are supported.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// key returns the key of the ID. The only key is returned for a token without ID.
+// Null entries of the keys are ignored.
+func (s *JWKS) key(kid string) (*JWK, error) {
+	var keys []*JWK
+	for _, k := range s.Keys {
+		if k == nil {
+			continue
+		}
+		if k.Kid == kid {
+			return k, nil
+		}
+		keys = append(keys, k)
+	}
+	if kid == "" && len(keys) == 1 {
+		return keys[0], nil
+	}
+	return nil, fmt.Errorf("key %q is not found in JWKS", kid)
+}
+
+// PublicKey returns the RSA public key. Keys shorter than 2048 bits are rejected as too weak.
+func (k *JWK) PublicKey() (*rsa.PublicKey, error) {
+	if k.Kty != "RSA" {
+		return nil, fmt.Errorf("key type %s is not supported", k.Kty)
+	}
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus of key %s: %w", k.Kid, err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent of key %s: %w", k.Kid, err)
+	}
+	exp := new(big.Int).SetBytes(e)
+	if !exp.IsInt64() || exp.Int64() < 2 || exp.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid exponent of key %s", k.Kid)
+	}
+	modulus := new(big.Int).SetBytes(n)
+	if modulus.BitLen() < minRSAKeyBits {
+		return nil, fmt.Errorf("key %s of %d bits is too short, at least %d bits are required", k.Kid, modulus.BitLen(), minRSAKeyBits)
+	}
+	return &rsa.PublicKey{N: modulus, E: int(exp.Int64())}, nil
+}
+
+// OIDCValidationOptions represents what an OIDC token is expected to be issued for.
+// Audience defaults to OrgID, which CircleCI uses unless a custom audience is set,
+// and ProjectID is checked only if given. Now defaults to time.Now.
+type OIDCValidationOptions struct {
+	OrgID     string
+	ProjectID string
+	Audience  string
+	Leeway    time.Duration
+	Now       func() time.Time
+}
+
+// OIDCToken represents the claims of an OIDC token issued by CircleCI.
+type OIDCToken struct {
+	Issuer     string
+	Subject    string
+	Audience   []string
+	IssuedAt   time.Time
+	ExpiresAt  time.Time
+	OrgID      string
+	ProjectID  string
+	ContextIDs []string
+	VCSOrigin  string
+	VCSRef     string
+}
+
+type oidcTokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type oidcTokenPayload struct {
+	Iss        string          `json:"iss"`
+	Sub        string          `json:"sub"`
+	Aud        json.RawMessage `json:"aud"`
+	Iat        int64           `json:"iat"`
+	Exp        int64           `json:"exp"`
+	ProjectID  string          `json:"oidc.circleci.com/project-id"`
+	ContextIDs []string        `json:"oidc.circleci.com/context-ids"`
+	VCSOrigin  string          `json:"oidc.circleci.com/vcs-origin"`
+	VCSRef     string          `json:"oidc.circleci.com/vcs-ref"`
+}
+
+// ValidateOIDCToken verifies the RS256 signature of the OIDC token with the JWKS offline,
+// and validates its issuer, audience, organization, project and expiry.
+// ErrOIDCTokenExpired is returned for an expired token.
+func ValidateOIDCToken(token string, jwks *JWKS, opts *OIDCValidationOptions) (*OIDCToken, error) {
+	if opts == nil || opts.OrgID == "" {
+		return nil, errors.New("organization ID is required to validate oidc token")
+	}
+	if jwks == nil {
+		return nil, errors.New("jwks is required to validate oidc token")
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("oidc token must have 3 parts")
+	}
+
+	header := &oidcTokenHeader{}
+	if err := decodeJWTPart(parts[0], header); err != nil {
+		return nil, fmt.Errorf("invalid header of oidc token: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("algorithm %s of oidc token is not supported", header.Alg)
+	}
+	jwk, err := jwks.key(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := jwk.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature of oidc token: %w", err)
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, hash[:], sig); err != nil {
+		return nil, fmt.Errorf("invalid signature of oidc token: %w", err)
+	}
+
+	payload := &oidcTokenPayload{}
+	if err := decodeJWTPart(parts[1], payload); err != nil {
+		return nil, fmt.Errorf("invalid payload of oidc token: %w", err)
+	}
+	t := &OIDCToken{
+		Issuer:     payload.Iss,
+		Subject:    payload.Sub,
+		IssuedAt:   time.Unix(payload.Iat, 0),
+		ExpiresAt:  time.Unix(payload.Exp, 0),
+		ProjectID:  payload.ProjectID,
+		ContextIDs: payload.ContextIDs,
+		VCSOrigin:  payload.VCSOrigin,
+		VCSRef:     payload.VCSRef,
+	}
+	t.Audience, err = jwtAudience(payload.Aud)
+	if err != nil {
+		return nil, err
+	}
+	// Subject is org/<org-id>/project/<project-id>/user/<user-id>.
+	if s := strings.Split(t.Subject, "/"); len(s) >= 2 && s[0] == "org" {
+		t.OrgID = s[1]
+	}
+	if err := t.validate(opts); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *OIDCToken) validate(opts *OIDCValidationOptions) error {
+	if t.Issuer != oidcIssuerPrefix+opts.OrgID {
+		return fmt.Errorf("issuer %s of oidc token is not the organization %s", t.Issuer, opts.OrgID)
+	}
+	if t.OrgID != opts.OrgID {
+		return fmt.Errorf("oidc token is issued for organization %s, not %s", t.OrgID, opts.OrgID)
+	}
+	audience := opts.Audience
+	if audience == "" {
+		audience = opts.OrgID
+	}
+	if !containsString(t.Audience, audience) {
+		return fmt.Errorf("audience %s of oidc token does not contain %s", strings.Join(t.Audience, ","), audience)
+	}
+	if opts.ProjectID != "" && t.ProjectID != opts.ProjectID {
+		return fmt.Errorf("oidc token is issued for project %s, not %s", t.ProjectID, opts.ProjectID)
+	}
+	now := time.Now()
+	if opts.Now != nil {
+		now = opts.Now()
+	}
+	if !now.Before(t.ExpiresAt.Add(opts.Leeway)) {
+		return ErrOIDCTokenExpired
+	}
+	if now.Add(opts.Leeway).Before(t.IssuedAt) {
+		return fmt.Errorf("oidc token is issued in the future at %s", t.IssuedAt)
+	}
+	return nil
+}
+
+func decodeJWTPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// jwtAudience decodes aud claim, which is a string or an array of strings.
+func jwtAudience(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var aud string
+	if err := json.Unmarshal(raw, &aud); err == nil {
+		return []string{aud}, nil
+	}
+	var auds []string
+	if err := json.Unmarshal(raw, &auds); err != nil {
+		return nil, fmt.Errorf("invalid audience of oidc token: %w", err)
+	}
+	return auds, nil
+}
@@ -0,0 +1,162 @@
+package circleci_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+func TestOIDCOp(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/org/org/project/prj/oidc-custom-claims", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PATCH":
+			update := &circleci.OIDCClaimsUpdate{}
+			if err := json.NewDecoder(r.Body).Decode(update); err != nil {
+				t.Fatal(err)
+			}
+			json.NewEncoder(w).Encode(&circleci.OIDCClaims{OrgID: "org", ProjectID: "prj", Audience: update.Audience, TTL: update.TTL})
+		case "DELETE":
+			if claims := r.URL.Query().Get("claims"); claims != "audience,ttl" {
+				t.Errorf("Invalid claims to delete. Actual: %s", claims)
+			}
+			w.Write([]byte(`{"org_id": "org", "project_id": "prj"}`))
+		default:
+			t.Errorf("Unexpected method %s", r.Method)
+		}
+	})
+	c := newTestClient(t, mux)
+
+	cl, err := c.OIDC.SetProjectClaims("org", "prj", &circleci.OIDCClaimsUpdate{Audience: []string{"sts.amazonaws.com"}, TTL: "30m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(cl.Audience, []string{"sts.amazonaws.com"}) || cl.TTL != "30m" {
+		t.Errorf("Invalid claims. Actual: %+v", cl)
+	}
+	if _, err := c.OIDC.DeleteProjectClaims("org", "prj", circleci.OIDCClaimAudience, circleci.OIDCClaimTTL); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func signJWT(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	encode := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := encode(map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"}) + "." + encode(claims)
+	hash := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hash[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestValidateOIDCToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := &circleci.JWKS{Keys: []*circleci.JWK{{
+		Kty: "RSA",
+		Kid: "k1",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	issuedAt := time.Date(2021, 3, 1, 10, 0, 0, 0, time.UTC)
+	claims := map[string]interface{}{
+		"iss":                           "https://oidc.circleci.com/org/org",
+		"sub":                           "org/org/project/prj/user/usr",
+		"aud":                           "org",
+		"iat":                           issuedAt.Unix(),
+		"exp":                           issuedAt.Add(time.Hour).Unix(),
+		"oidc.circleci.com/project-id":  "prj",
+		"oidc.circleci.com/context-ids": []string{"ctx"},
+		"oidc.circleci.com/vcs-origin":  "github.com/org/repo",
+		"oidc.circleci.com/vcs-ref":     "refs/heads/main",
+	}
+	opts := &circleci.OIDCValidationOptions{
+		OrgID:     "org",
+		ProjectID: "prj",
+		Now:       func() time.Time { return issuedAt.Add(time.Minute) },
+	}
+
+	token, err := circleci.ValidateOIDCToken(signJWT(t, key, "k1", claims), jwks, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.OrgID != "org" || token.ProjectID != "prj" || token.VCSRef != "refs/heads/main" || !token.ExpiresAt.Equal(issuedAt.Add(time.Hour)) {
+		t.Errorf("Invalid token. Actual: %+v", token)
+	}
+
+	expired := *opts
+	expired.Now = func() time.Time { return issuedAt.Add(2 * time.Hour) }
+	if _, err := circleci.ValidateOIDCToken(signJWT(t, key, "k1", claims), jwks, &expired); err != circleci.ErrOIDCTokenExpired {
+		t.Errorf("Expected ErrOIDCTokenExpired. Actual: %v", err)
+	}
+
+	other := *opts
+	other.ProjectID = "other"
+	if _, err := circleci.ValidateOIDCToken(signJWT(t, key, "k1", claims), jwks, &other); err == nil {
+		t.Error("Expected an error of project")
+	}
+
+	claims["aud"] = []string{"sts.amazonaws.com"}
+	if _, err := circleci.ValidateOIDCToken(signJWT(t, key, "k1", claims), jwks, opts); err == nil {
+		t.Error("Expected an error of audience")
+	}
+	custom := *opts
+	custom.Audience = "sts.amazonaws.com"
+	if _, err := circleci.ValidateOIDCToken(signJWT(t, key, "k1", claims), jwks, &custom); err != nil {
+		t.Errorf("Custom audience must be accepted. Actual: %v", err)
+	}
+
+	tampered := signJWT(t, key, "k1", claims)
+	tampered = tampered[:len(tampered)-4] + "AAAA"
+	if _, err := circleci.ValidateOIDCToken(tampered, jwks, &custom); err == nil {
+		t.Error("Expected an error of signature")
+	}
+	if _, err := circleci.ValidateOIDCToken(signJWT(t, key, "k2", claims), jwks, &custom); err == nil {
+		t.Error("Expected an error of unknown key")
+	}
+	if _, err := circleci.ValidateOIDCToken(signJWT(t, key, "k1", claims), nil, &custom); err == nil {
+		t.Error("Expected an error of nil JWKS")
+	}
+
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weakJWKS := &circleci.JWKS{Keys: []*circleci.JWK{{
+		Kty: "RSA",
+		Kid: "weak",
+		N:   base64.RawURLEncoding.EncodeToString(weak.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(weak.E)).Bytes()),
+	}}}
+	if _, err := circleci.ValidateOIDCToken(signJWT(t, weak, "weak", claims), weakJWKS, &custom); err == nil {
+		t.Error("Expected an error of a key shorter than 2048 bits")
+	}
+
+	withNull := &circleci.JWKS{Keys: []*circleci.JWK{nil, jwks.Keys[0]}}
+	if _, err := circleci.ValidateOIDCToken(signJWT(t, key, "k1", claims), withNull, &custom); err != nil {
+		t.Errorf("Null key must be ignored. Actual: %v", err)
+	}
+	if _, err := circleci.ValidateOIDCToken(signJWT(t, key, "", claims), &circleci.JWKS{Keys: []*circleci.JWK{nil}}, &custom); err == nil {
+		t.Error("Expected an error of no key")
+	}
+}
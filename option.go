@@ -1,7 +1,9 @@
 package circleci
 
 import (
+	"crypto/tls"
 	"net/http"
+	"net/url"
 )
 
 // Option is used to configure client with options
@@ -24,3 +26,99 @@ func WithPathPrefix(path string) Option {
 		c.pathPrefix = path
 	}
 }
+
+// WithV1PathPrefix optionally sets the API Prefix used for API v1.1.
+// This can be used when would like to mock or use different prefix.
+func WithV1PathPrefix(path string) Option {
+	return func(c *Client) {
+		c.v1PathPrefix = path
+	}
+}
+
+// WithBaseURL optionally sets the base URL of CircleCI API.
+// This can be used when would like to connect to CircleCI server, i.e. self-hosted installation.
+func WithBaseURL(u *url.URL) Option {
+	return func(c *Client) {
+		if u != nil {
+			copied := *u
+			c.BaseURL = &copied
+		}
+	}
+}
+
+// WithRunnerURL optionally sets the base URL of self-hosted runner API.
+func WithRunnerURL(u *url.URL) Option {
+	return func(c *Client) {
+		if u != nil {
+			copied := *u
+			c.RunnerURL = &copied
+		}
+	}
+}
+
+// WithWebhookURL optionally sets the base URL of webhook API.
+func WithWebhookURL(u *url.URL) Option {
+	return func(c *Client) {
+		if u != nil {
+			copied := *u
+			c.WebhookURL = &copied
+		}
+	}
+}
+
+// WithTLSConfig optionally sets the TLS configuration of the HTTP client.
+// This can be used when CircleCI server is served with a certificate of private CA. See also NewTLSConfig.
+// The transport of the http.Client is replaced unless it is *http.Transport, thus give it after WithHTTPClient.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *Client) {
+		var t *http.Transport
+		if ht, ok := c.HTTPClient.Transport.(*http.Transport); ok && ht != nil {
+			t = ht.Clone()
+		} else {
+			t = http.DefaultTransport.(*http.Transport).Clone()
+		}
+		t.TLSClientConfig = cfg
+		hc := *c.HTTPClient
+		hc.Transport = t
+		c.HTTPClient = &hc
+	}
+}
+
+// WithAuthenticator optionally sets the Authenticator of requests in place of the API token given to NewClient.
+func WithAuthenticator(a Authenticator) Option {
+	return func(c *Client) {
+		c.auth = a
+	}
+}
+
+// WithHook optionally adds the Hook to observe requests.
+// Hooks are called in the order given.
+func WithHook(h Hook) Option {
+	return func(c *Client) {
+		if h != nil {
+			c.hooks = append(c.hooks, h)
+		}
+	}
+}
+
+// WithCache optionally sets the Cache of responses of GET requests.
+func WithCache(cache *Cache) Option {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// WithMaxResponseBodySize optionally sets the maximum size of response body to decode (defaults to 64 MiB).
+// ErrBodyTooLarge is returned for larger responses. Zero or negative size disables the limit.
+func WithMaxResponseBodySize(size int64) Option {
+	return func(c *Client) {
+		c.maxBodySize = size
+	}
+}
+
+// WithRateLimiter optionally sets the RateLimiter of requests. See also NewRateLimiter.
+func WithRateLimiter(l RateLimiter) Option {
+	return func(c *Client) {
+		c.limiter = l
+	}
+}
@@ -0,0 +1,273 @@
+package circleci
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ttyfky/go-circleci/config"
+)
+
+const graphQLPath = "/graphql-unstable"
+
+var (
+	// orbNamePattern is the naming rule of orbs, i.e. namespace/name.
+	orbNamePattern = regexp.MustCompile(`^[a-z0-9-]+/[a-z0-9-]+$`)
+	// orbVersionPattern matches versions such as 5.0.2, 5, volatile and dev:alpha.
+	orbVersionPattern = regexp.MustCompile(`^(?:[0-9]+(?:\.[0-9]+){0,2}|volatile|dev:[A-Za-z0-9_-]+)$`)
+)
+
+// OrbService is an interface for the orb registry, which is served by the GraphQL API.
+type OrbService interface {
+	Get(name string) (*Orb, error)
+	ListVersions(name string) ([]*OrbVersion, error)
+	Source(ref string) (string, error)
+}
+
+// OrbOp handles communication with the orb registry of CircleCI.
+type OrbOp struct {
+	client *Client
+}
+
+var _ OrbService = (*OrbOp)(nil)
+
+// Orb represents an orb in the registry, e.g. circleci/node.
+// Versions are sorted from the latest.
+type Orb struct {
+	ID        string        `json:"id,omitempty"`
+	Name      string        `json:"name,omitempty"`
+	CreatedAt time.Time     `json:"createdAt,omitempty"`
+	Versions  []*OrbVersion `json:"versions,omitempty"`
+}
+
+// OrbVersion represents a published version of an orb.
+type OrbVersion struct {
+	Version   string    `json:"version,omitempty"`
+	CreatedAt time.Time `json:"createdAt,omitempty"`
+}
+
+// GraphQLError represents errors returned by the GraphQL API with a successful status.
+type GraphQLError struct {
+	Messages []string
+}
+
+func (e *GraphQLError) Error() string {
+	return "graphql: " + strings.Join(e.Messages, "; ")
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   interface{} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// graphQL posts the query and decodes its data into v.
+func (c *Client) graphQL(query string, variables map[string]interface{}, v interface{}) error {
+	resp := &graphQLResponse{Data: v}
+	err := c.createAndDo("/", "POST", graphQLPath, &graphQLRequest{Query: query, Variables: variables}, nil, resp)
+	if err != nil {
+		return err
+	}
+	if len(resp.Errors) != 0 {
+		e := &GraphQLError{}
+		for _, m := range resp.Errors {
+			e.Messages = append(e.Messages, m.Message)
+		}
+		return e
+	}
+	return nil
+}
+
+const orbQuery = `query($name: String!) {
+  orb(name: $name) {
+    id
+    name
+    createdAt
+    versions(count: 200) {
+      version
+      createdAt
+    }
+  }
+}`
+
+// Get gets the orb with its versions, e.g. circleci/node.
+func (ps *OrbOp) Get(name string) (*Orb, error) {
+	var data struct {
+		Orb *Orb `json:"orb"`
+	}
+	err := ps.client.graphQL(orbQuery, map[string]interface{}{"name": name}, &data)
+	if err != nil {
+		return nil, err
+	}
+	if data.Orb == nil {
+		return nil, fmt.Errorf("orb %s is not found", name)
+	}
+	sort.SliceStable(data.Orb.Versions, func(i, j int) bool {
+		return compareOrbVersions(data.Orb.Versions[i].Version, data.Orb.Versions[j].Version) > 0
+	})
+	return data.Orb, nil
+}
+
+// ListVersions lists the versions of the orb from the latest.
+func (ps *OrbOp) ListVersions(name string) ([]*OrbVersion, error) {
+	o, err := ps.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return o.Versions, nil
+}
+
+const orbSourceQuery = `query($ref: String!) {
+  orbVersion(orbVersionRef: $ref) {
+    version
+    source
+  }
+}`
+
+// Source gets the source of the orb version, e.g. circleci/node@5.0.2.
+func (ps *OrbOp) Source(ref string) (string, error) {
+	var data struct {
+		OrbVersion *struct {
+			Version string `json:"version"`
+			Source  string `json:"source"`
+		} `json:"orbVersion"`
+	}
+	err := ps.client.graphQL(orbSourceQuery, map[string]interface{}{"ref": ref}, &data)
+	if err != nil {
+		return "", err
+	}
+	if data.OrbVersion == nil {
+		return "", fmt.Errorf("orb %s is not found", ref)
+	}
+	return data.OrbVersion.Source, nil
+}
+
+// OrbUpdate represents whether an orb imported by a config is pinned to the latest version.
+// A partial version, e.g. 5 or 5.1, is outdated only if the latest version is out of it.
+type OrbUpdate struct {
+	Alias    string `json:"alias"`
+	Name     string `json:"name"`
+	Current  string `json:"current"`
+	Latest   string `json:"latest"`
+	Outdated bool   `json:"outdated"`
+}
+
+// OutdatedOrbs checks the versions of the orbs imported by the config against the registry.
+// Inline orbs and orbs pinned to volatile or dev versions are skipped.
+func (c *Client) OutdatedOrbs(cfg *config.Config) ([]*OrbUpdate, error) {
+	aliases := make([]string, 0, len(cfg.Orbs))
+	for alias := range cfg.Orbs {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	var updates []*OrbUpdate
+	for _, alias := range aliases {
+		o := cfg.Orbs[alias]
+		if o.Inline != nil {
+			continue
+		}
+		i := strings.LastIndex(o.Ref, "@")
+		if i < 0 {
+			continue
+		}
+		name, current := o.Ref[:i], o.Ref[i+1:]
+		if current == "volatile" || strings.HasPrefix(current, "dev:") {
+			continue
+		}
+		versions, err := c.Orb.ListVersions(name)
+		if err != nil {
+			return nil, err
+		}
+		u := &OrbUpdate{Alias: alias, Name: name, Current: current}
+		if len(versions) != 0 {
+			u.Latest = versions[0].Version
+			u.Outdated = compareOrbVersions(u.Latest, current) > 0 && !strings.HasPrefix(u.Latest, current+".")
+		}
+		updates = append(updates, u)
+	}
+	return updates, nil
+}
+
+// compareOrbVersions compares semantic versions such as 5.0.2, returning a positive number if a is newer than b.
+func compareOrbVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, _ := strconv.Atoi(as[i])
+		y, _ := strconv.Atoi(bs[i])
+		if x != y {
+			return x - y
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// OrbCache resolves orbs for config.Process, caching their sources under Dir as <namespace>/<orb>@<version>.yml.
+// Orbs missing in Dir are fetched from the registry by Orbs, or fail if Orbs is nil to work offline.
+type OrbCache struct {
+	Dir  string
+	Orbs OrbService
+}
+
+var _ config.OrbResolver = (*OrbCache)(nil)
+
+// NewOrbCache returns an OrbCache. Pass nil orbs to work offline.
+func NewOrbCache(dir string, orbs OrbService) *OrbCache {
+	return &OrbCache{Dir: dir, Orbs: orbs}
+}
+
+// OrbSource returns the source of the orb, e.g. circleci/node@5.0.2.
+func (oc *OrbCache) OrbSource(ref string) ([]byte, error) {
+	i := strings.LastIndex(ref, "@")
+	if i < 0 || !orbNamePattern.MatchString(ref[:i]) || !orbVersionPattern.MatchString(ref[i+1:]) {
+		return nil, fmt.Errorf("invalid orb reference %s", ref)
+	}
+	version := ref[i+1:]
+	if version == "volatile" || strings.HasPrefix(version, "dev:") || strings.Count(version, ".") != 2 {
+		// Sources of versions which may change are not cached.
+		return oc.fetch(ref)
+	}
+
+	file := filepath.Join(oc.Dir, filepath.FromSlash(ref)+".yml")
+	source, err := ioutil.ReadFile(file)
+	if err == nil {
+		return source, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+	source, err = oc.fetch(ref)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(file, source, 0o644); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+func (oc *OrbCache) fetch(ref string) ([]byte, error) {
+	if oc.Orbs == nil {
+		return nil, fmt.Errorf("orb %s is not cached", ref)
+	}
+	source, err := oc.Orbs.Source(ref)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(source), nil
+}
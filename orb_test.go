@@ -0,0 +1,124 @@
+package circleci_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ttyfky/go-circleci"
+	"github.com/ttyfky/go-circleci/config"
+)
+
+// orbRegistry serves orb queries of the GraphQL API.
+func orbRegistry(t *testing.T, fetched *int) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/graphql-unstable", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]string `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatal(err)
+		}
+		switch {
+		case req.Variables["name"] == "circleci/node":
+			w.Write([]byte(`{"data": {"orb": {"name": "circleci/node", "versions": [
+				{"version": "5.0.2"}, {"version": "5.10.0"}, {"version": "4.7.0"}]}}}`))
+		case req.Variables["ref"] == "circleci/node@5.0.2":
+			*fetched++
+			w.Write([]byte(`{"data": {"orbVersion": {"version": "5.0.2", "source": "version: 2.1\n"}}}`))
+		default:
+			w.Write([]byte(`{"data": {"orb": null}, "errors": [{"message": "not found"}]}`))
+		}
+	})
+	return mux
+}
+
+func TestOrbOp_Get(t *testing.T) {
+	c := newTestClient(t, orbRegistry(t, new(int)))
+
+	versions, err := c.Orb.ListVersions("circleci/node")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual []string
+	for _, v := range versions {
+		actual = append(actual, v.Version)
+	}
+	expected := []string{"5.10.0", "5.0.2", "4.7.0"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Versions must be sorted from the latest. Expected: %v, Actual: %v", expected, actual)
+	}
+
+	_, err = c.Orb.Get("circleci/missing")
+	if _, ok := err.(*circleci.GraphQLError); !ok {
+		t.Errorf("Expected GraphQLError. Actual: %v", err)
+	}
+}
+
+func TestClient_OutdatedOrbs(t *testing.T) {
+	c := newTestClient(t, orbRegistry(t, new(int)))
+	cfg, err := config.Parse([]byte(`version: 2.1
+orbs:
+  node: circleci/node@5.0.2
+  node5: circleci/node@5
+  node4: circleci/node@4.7
+  dev: circleci/node@dev:alpha
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updates, err := c.OutdatedOrbs(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []*circleci.OrbUpdate{
+		{Alias: "node", Name: "circleci/node", Current: "5.0.2", Latest: "5.10.0", Outdated: true},
+		{Alias: "node4", Name: "circleci/node", Current: "4.7", Latest: "5.10.0", Outdated: true},
+		{Alias: "node5", Name: "circleci/node", Current: "5", Latest: "5.10.0"},
+	}
+	if !reflect.DeepEqual(updates, expected) {
+		got, _ := json.Marshal(updates)
+		t.Errorf("Invalid updates. Actual: %s", got)
+	}
+}
+
+func TestOrbCache(t *testing.T) {
+	fetched := 0
+	c := newTestClient(t, orbRegistry(t, &fetched))
+	dir := t.TempDir()
+
+	cache := circleci.NewOrbCache(dir, c.Orb)
+	for i := 0; i < 2; i++ {
+		source, err := cache.OrbSource("circleci/node@5.0.2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(source) != "version: 2.1\n" {
+			t.Errorf("Invalid source. Actual: %q", source)
+		}
+	}
+	if fetched != 1 {
+		t.Errorf("Source must be fetched once. Actual: %d", fetched)
+	}
+	if _, err := ioutil.ReadFile(filepath.Join(dir, "circleci", "node@5.0.2.yml")); err != nil {
+		t.Error(err)
+	}
+
+	offline := circleci.NewOrbCache(dir, nil)
+	if _, err := offline.OrbSource("circleci/node@5.0.2"); err != nil {
+		t.Errorf("Cached source must be available offline. Actual: %v", err)
+	}
+	if _, err := offline.OrbSource("circleci/go@1.7.0"); err == nil {
+		t.Error("Expected an error for an orb not cached")
+	}
+	for _, ref := range []string{"../../x@1.0.0", "circleci/../x@1.0.0", "/etc/x@1.0.0", "circleci/node@../1.0.0", "circleci/node"} {
+		if _, err := cache.OrbSource(ref); err == nil || !strings.Contains(err.Error(), "invalid orb reference") {
+			t.Errorf("Expected an error for %s. Actual: %v", ref, err)
+		}
+	}
+}
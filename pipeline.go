@@ -2,6 +2,67 @@ package circleci
 
 import "time"
 
+const pipelineBasePath = "/pipeline"
+
+// PipelineService is an interface for Pipeline API.
+type PipelineService interface {
+	List(projectSlug string, opts *PipelineListOptions) (*PipelineList, error)
+	Get(id string) (*Pipeline, error)
+	GetConfig(id string) (*PipelineConfig, error)
+	ListWorkflows(id string) ([]Workflow, error)
+	Trigger(projectSlug string, opts *PipelineTriggerOptions) (*PipelineTrigger, error)
+}
+
+// PipelineOp handles communication with the pipeline related methods in the CircleCI API v2.
+type PipelineOp struct {
+	client *Client
+}
+
+var _ PipelineService = (*PipelineOp)(nil)
+
+// PipelineListOptions represents options to list pipelines of a project.
+type PipelineListOptions struct {
+	Branch    string `url:"branch,omitempty"`
+	PageToken string `url:"page-token,omitempty"`
+}
+
+// PipelineTriggerOptions represents parameters to trigger a pipeline.
+// Branch and Tag are exclusive. The default branch is used if both are empty.
+type PipelineTriggerOptions struct {
+	Branch     string                 `json:"branch,omitempty"`
+	Tag        string                 `json:"tag,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+}
+
+// PipelineTrigger represents a pipeline created by Trigger.
+type PipelineTrigger struct {
+	ID        string    `json:"id,omitempty"`
+	State     string    `json:"state,omitempty"`
+	Number    int       `json:"number,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"`
+}
+
+// PipelineConfig represents the configuration of a pipeline, as written and as compiled by CircleCI.
+// Setup configs are set only for pipelines of setup workflows.
+type PipelineConfig struct {
+	Source              string `json:"source,omitempty"`
+	Compiled            string `json:"compiled,omitempty"`
+	SetupConfig         string `json:"setup-config,omitempty"`
+	CompiledSetupConfig string `json:"compiled-setup-config,omitempty"`
+}
+
+// PipelineList represents a list of Pipeline.
+type PipelineList struct {
+	Items         []Pipeline `json:"items,omitempty"`
+	NextPageToken string     `json:"next_page_token,omitempty"`
+}
+
+// PipelineWorkflowList represents a list of Workflow in a Pipeline.
+type PipelineWorkflowList struct {
+	Items         []Workflow `json:"items,omitempty"`
+	NextPageToken string     `json:"next_page_token,omitempty"`
+}
+
 // Pipeline represents pipeline in CircleCI.
 type Pipeline struct {
 	ID     string `json:"id,omitempty"`
@@ -36,4 +97,68 @@ type Pipeline struct {
 		} `json:"commit"`
 		OriginRepositoryURL string `json:"origin_repository_url"`
 	} `json:"vcs"`
+	// TriggerParameters are the pipeline parameters given when the pipeline was triggered.
+	TriggerParameters map[string]interface{} `json:"trigger_parameters,omitempty"`
+}
+
+// List lists pipelines of the project, most recent first.
+func (ps *PipelineOp) List(projectSlug string, opts *PipelineListOptions) (*PipelineList, error) {
+	pl := &PipelineList{}
+	err := ps.client.Get(projectPathPrefix(projectSlug)+pipelineBasePath, pl, opts)
+	if err != nil {
+		return nil, err
+	}
+	return pl, nil
+}
+
+// Get gets detail of pipeline.
+func (ps *PipelineOp) Get(id string) (*Pipeline, error) {
+	p := &Pipeline{}
+	err := ps.client.Get(pipelineBasePath+"/"+id, p, nil)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetConfig gets the source and compiled configuration of the pipeline.
+func (ps *PipelineOp) GetConfig(id string) (*PipelineConfig, error) {
+	pc := &PipelineConfig{}
+	err := ps.client.Get(pipelineBasePath+"/"+id+"/config", pc, nil)
+	if err != nil {
+		return nil, err
+	}
+	return pc, nil
+}
+
+// ListWorkflows lists all workflows of the pipeline by following every page.
+func (ps *PipelineOp) ListWorkflows(id string) ([]Workflow, error) {
+	var workflows []Workflow
+	path := pipelineBasePath + "/" + id + "/workflow"
+	opts := &PageOptions{}
+	for {
+		wl := &PipelineWorkflowList{}
+		err := ps.client.Get(path, wl, opts)
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, wl.Items...)
+		if wl.NextPageToken == "" {
+			return workflows, nil
+		}
+		opts.PageToken = wl.NextPageToken
+	}
+}
+
+// Trigger triggers a new pipeline of the project.
+func (ps *PipelineOp) Trigger(projectSlug string, opts *PipelineTriggerOptions) (*PipelineTrigger, error) {
+	if opts == nil {
+		opts = &PipelineTriggerOptions{}
+	}
+	pt := &PipelineTrigger{}
+	err := ps.client.Post(projectPathPrefix(projectSlug)+pipelineBasePath, opts, pt)
+	if err != nil {
+		return nil, err
+	}
+	return pt, nil
 }
@@ -0,0 +1,93 @@
+package circleci
+
+import "github.com/ttyfky/go-circleci/config"
+
+// PipelineConfigDiff represents differences between the source and the compiled config of a pipeline.
+//
+// Source is processed offline with the branch, tag and trigger parameters of the pipeline before being
+// compared, so that only differences CircleCI made are reported. When Source can not be processed, e.g.
+// since it imports orbs and no OrbResolver is given, ProcessError is set and Source is compared as it is.
+type PipelineConfigDiff struct {
+	Source       *config.Config
+	Compiled     *config.Config
+	Processed    bool
+	ProcessError error
+	Changes      []config.Change
+}
+
+// DiffPipelineConfig compares the source config of the pipeline with the one compiled by CircleCI.
+// Options given by opts, e.g. Orbs, are used to process the source and take precedence over the values of the pipeline.
+func (c *Client) DiffPipelineConfig(id string, opts *config.ProcessOptions) (*PipelineConfigDiff, error) {
+	p, err := c.Pipeline.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	pc, err := c.Pipeline.GetConfig(id)
+	if err != nil {
+		return nil, err
+	}
+	source, err := config.Parse([]byte(pc.Source))
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := config.Parse([]byte(pc.Compiled))
+	if err != nil {
+		return nil, err
+	}
+
+	d := &PipelineConfigDiff{Source: source, Compiled: compiled}
+	from := source
+	processed, err := config.Process(source, pipelineProcessOptions(p, source, opts))
+	if err != nil {
+		d.ProcessError = err
+	} else {
+		from, d.Processed = processed, true
+	}
+	d.Changes = config.Diff(from, compiled)
+	return d, nil
+}
+
+// pipelineProcessOptions returns the options to process the config as CircleCI did for the pipeline, overridden by opts.
+func pipelineProcessOptions(p *Pipeline, c *config.Config, opts *config.ProcessOptions) *config.ProcessOptions {
+	o := config.ProcessOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Branch == "" && o.Tag == "" {
+		o.Branch, o.Tag = p.Vcs.Branch, p.Vcs.Tag
+	}
+
+	values := map[string]interface{}{}
+	if p.ID != "" {
+		values["pipeline.id"] = p.ID
+	}
+	if p.Number != 0 {
+		values["pipeline.number"] = p.Number
+	}
+	if p.Vcs.Revision != "" {
+		values["pipeline.git.revision"] = p.Vcs.Revision
+	}
+	for k, v := range o.Values {
+		values[k] = v
+	}
+	o.Values = values
+
+	// Only parameters declared by the config are passed, since undeclared ones fail the process.
+	params := map[string]interface{}{}
+	for name, param := range c.Parameters {
+		v, ok := p.TriggerParameters[name]
+		if !ok {
+			continue
+		}
+		// Numbers are decoded from JSON as float64 while integer parameters require int.
+		if f, ok := v.(float64); ok && param.Type == config.ParameterInteger && f == float64(int(f)) {
+			v = int(f)
+		}
+		params[name] = v
+	}
+	for k, v := range o.Parameters {
+		params[k] = v
+	}
+	o.Parameters = params
+	return &o
+}
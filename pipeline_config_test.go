@@ -0,0 +1,164 @@
+package circleci_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/ttyfky/go-circleci"
+	"github.com/ttyfky/go-circleci/config"
+)
+
+func TestClient_DiffPipelineConfig(t *testing.T) {
+	source := `version: 2.1
+commands:
+  test:
+    steps:
+      - run: go test ./...
+jobs:
+  build:
+    docker:
+      - image: cimg/go:1.16
+    steps:
+      - checkout
+      - test
+workflows:
+  main:
+    when:
+      equal: [main, << pipeline.git.branch >>]
+    jobs:
+      - build
+`
+	compiled := `version: 2
+jobs:
+  build:
+    docker:
+      - image: cimg/go:1.16
+    steps:
+      - checkout
+      - run:
+          command: go test ./...
+      - run:
+          command: echo injected
+workflows:
+  version: 2
+  main:
+    jobs:
+      - build
+`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/pipeline/p1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "p1", "vcs": {"branch": "main"}}`))
+	})
+	mux.HandleFunc("/api/v2/pipeline/p1/config", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&circleci.PipelineConfig{Source: source, Compiled: compiled})
+	})
+	c := newTestClient(t, mux)
+
+	d, err := c.DiffPipelineConfig("p1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Processed {
+		t.Fatalf("Source must be processed. Error: %v", d.ProcessError)
+	}
+	if len(d.Changes) != 1 || d.Changes[0].String() != "+ jobs.build.steps[2]: {run: {command: echo injected}}" {
+		t.Errorf("Invalid changes. Actual: %v", d.Changes)
+	}
+}
+
+type orbSources map[string]string
+
+func (o orbSources) OrbSource(ref string) ([]byte, error) {
+	s, ok := o[ref]
+	if !ok {
+		return nil, fmt.Errorf("orb %s not found", ref)
+	}
+	return []byte(s), nil
+}
+
+func TestClient_DiffPipelineConfig_Options(t *testing.T) {
+	source := `version: 2.1
+orbs:
+  tools: acme/tools@1.0.0
+parameters:
+  deploy:
+    type: boolean
+    default: false
+  replicas:
+    type: integer
+    default: 1
+jobs:
+  build:
+    docker:
+      - image: cimg/go:1.16
+    parallelism: << pipeline.parameters.replicas >>
+    steps:
+      - tools/greet
+workflows:
+  main:
+    when: << pipeline.parameters.deploy >>
+    jobs:
+      - build
+`
+	compiled := `version: 2
+jobs:
+  build:
+    docker:
+      - image: cimg/go:1.16
+    parallelism: 3
+    steps:
+      - run:
+          command: echo hello
+workflows:
+  version: 2
+  main:
+    jobs:
+      - build
+`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/pipeline/p1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "p1", "vcs": {"branch": "main"}, "trigger_parameters": {"deploy": true, "replicas": 3, "other": "x"}}`))
+	})
+	mux.HandleFunc("/api/v2/pipeline/p1/config", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(&circleci.PipelineConfig{Source: source, Compiled: compiled})
+	})
+	c := newTestClient(t, mux)
+
+	d, err := c.DiffPipelineConfig("p1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Processed || d.ProcessError == nil {
+		t.Errorf("Source must not be processed without orb resolver.")
+	}
+
+	orbs := orbSources{"acme/tools@1.0.0": `version: 2.1
+commands:
+  greet:
+    steps:
+      - run: echo hello
+`}
+	d, err = c.DiffPipelineConfig("p1", &config.ProcessOptions{Orbs: orbs})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Processed {
+		t.Fatalf("Source must be processed. Error: %v", d.ProcessError)
+	}
+	if len(d.Changes) != 0 {
+		t.Errorf("Trigger parameters must be applied. Changes: %v", d.Changes)
+	}
+
+	d, err = c.DiffPipelineConfig("p1", &config.ProcessOptions{Orbs: orbs, Parameters: map[string]interface{}{"deploy": false}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Processed {
+		t.Fatalf("Source must be processed. Error: %v", d.ProcessError)
+	}
+	if len(d.Changes) != 2 || d.Changes[1].String() != "+ workflows.main: {jobs: [build]}" {
+		t.Errorf("Parameters of options must take precedence. Changes: %v", d.Changes)
+	}
+}
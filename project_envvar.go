@@ -7,6 +7,7 @@ type ProjectEnvVarService interface {
 	Create(projectSlug, name, value string) (*ProjectEnvVar, error)
 	Get(projectSlug, name string) (*ProjectEnvVar, error)
 	List(projectSlug string) (*ProjectEnvVarList, error)
+	ListAll(projectSlug string) ([]*ProjectEnvVar, error)
 	Delete(projectSlug, name string) error
 }
 
@@ -62,6 +63,25 @@ func (ps *ProjectEnvVarOp) List(projectSlug string) (*ProjectEnvVarList, error)
 	return evp, nil
 }
 
+// ListAll lists all environment variables of the specified project by following every page.
+// Returns the env vars (the value will be masked).
+func (ps *ProjectEnvVarOp) ListAll(projectSlug string) ([]*ProjectEnvVar, error) {
+	var items []*ProjectEnvVar
+	opts := &PageOptions{}
+	for {
+		evp := &ProjectEnvVarList{}
+		err := ps.client.Get(envVarPathPrefix(projectSlug), evp, opts)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, evp.Items...)
+		if evp.NextPageToken == "" {
+			return items, nil
+		}
+		opts.PageToken = evp.NextPageToken
+	}
+}
+
 // Delete deletes the specified environment variable from the project.
 func (ps *ProjectEnvVarOp) Delete(projectSlug, name string) error {
 	return ps.client.Delete(envVarValuePathPrefix(projectSlug, name))
@@ -0,0 +1,114 @@
+package circleci
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RateLimiter limits the rate of requests made by Client.
+type RateLimiter interface {
+	// Wait blocks until a request is allowed or ctx is done.
+	Wait(ctx context.Context) error
+}
+
+// NewRateLimiter creates a token bucket RateLimiter which allows rps requests per second with bursts of up to burst.
+// It panics unless rps is a positive finite number, since zero would mean no request is ever allowed;
+// give no RateLimiter to the client not to limit requests. Burst less than 1 is regarded as 1.
+func NewRateLimiter(rps float64, burst int) RateLimiter {
+	interval := float64(time.Second) / rps
+	if !(rps > 0) || math.IsInf(rps, 0) || interval < 1 || interval > math.MaxInt64 {
+		panic(fmt.Sprintf("circleci: invalid rate limit %v requests per second", rps))
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		interval: time.Duration(interval),
+		burst:    burst,
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+type tokenBucket struct {
+	interval time.Duration
+	burst    int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens += float64(now.Sub(b.last)) / float64(b.interval)
+		if b.tokens > float64(b.burst) {
+			b.tokens = float64(b.burst)
+		}
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) * float64(b.interval))
+		b.mu.Unlock()
+
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// waitRateLimit blocks until the client is allowed to make a request.
+// The client pauses after CircleCI tells the rate limit is exceeded, in addition to its RateLimiter.
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	c.mu.Lock()
+	until := c.pausedUntil
+	c.mu.Unlock()
+	if err := sleep(ctx, time.Until(until)); err != nil {
+		return err
+	}
+	if c.limiter != nil {
+		return c.limiter.Wait(ctx)
+	}
+	return nil
+}
+
+// observeRateLimit pauses the client when the response tells the rate limit is exceeded.
+func (c *Client) observeRateLimit(r *Response) {
+	var until time.Time
+	switch {
+	case r.StatusCode == http.StatusTooManyRequests && r.RateLimit.RetryAfter > 0:
+		until = time.Now().Add(r.RateLimit.RetryAfter)
+	case r.RateLimit.Limit > 0 && r.RateLimit.Remaining == 0 && !r.RateLimit.Reset.IsZero():
+		until = r.RateLimit.Reset
+	default:
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if until.After(c.pausedUntil) {
+		c.pausedUntil = until
+	}
+}
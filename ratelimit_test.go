@@ -0,0 +1,34 @@
+package circleci_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	l := circleci.NewRateLimiter(100, 2)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := l.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Errorf("The third request must wait for a token. Actual: %s", elapsed)
+	}
+
+	for _, rps := range []float64{0, -1, math.NaN(), math.Inf(1), 1e-12} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected a panic for %v", rps)
+				}
+			}()
+			circleci.NewRateLimiter(rps, 1)
+		}()
+	}
+}
@@ -0,0 +1,97 @@
+package circleci
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Response represents an HTTP response from CircleCI.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	RateLimit  RateLimit
+	// RequestID identifies the request in CircleCI, which is helpful for support tickets.
+	RequestID string
+	// Cached is true when the response is served from the cache of the client.
+	Cached bool
+}
+
+// RateLimit represents rate limit information in response headers.
+// Fields are zero when the headers are absent.
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	// Reset is when the rate limit window resets.
+	Reset time.Time
+	// RetryAfter is how long to wait before retrying, given with 429 Too Many Requests.
+	RetryAfter time.Duration
+}
+
+func newResponse(resp *http.Response) *Response {
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		RateLimit:  parseRateLimit(resp.Header, time.Now()),
+		RequestID:  resp.Header.Get("X-Request-Id"),
+	}
+}
+
+func firstHeader(h http.Header, names ...string) string {
+	for _, n := range names {
+		if v := h.Get(n); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func headerInt(h http.Header, names ...string) (int, bool) {
+	v := firstHeader(h, names...)
+	if v == "" {
+		return 0, false
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return i, true
+}
+
+func parseRateLimit(h http.Header, now time.Time) RateLimit {
+	var rl RateLimit
+	rl.Limit, _ = headerInt(h, "X-RateLimit-Limit", "RateLimit-Limit")
+	rl.Remaining, _ = headerInt(h, "X-RateLimit-Remaining", "RateLimit-Remaining")
+	if reset, ok := headerInt(h, "X-RateLimit-Reset", "RateLimit-Reset"); ok {
+		// Reset is given either in epoch seconds or in seconds from now.
+		if reset > 1e9 {
+			rl.Reset = time.Unix(int64(reset), 0)
+		} else {
+			rl.Reset = now.Add(time.Duration(reset) * time.Second)
+		}
+	}
+	if v := h.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			rl.RetryAfter = time.Duration(secs) * time.Second
+		} else if t, err := http.ParseTime(v); err == nil {
+			rl.RetryAfter = t.Sub(now)
+		}
+	}
+	return rl
+}
+
+func (c *Client) setLastResponse(r *Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastResponse = r
+}
+
+// LastResponse returns the Response of the last request made by the client, which is nil before any request.
+// It is only meaningful for sequential use: the client shares it across goroutines, and requests made concurrently,
+// e.g. by Batch or CancelAll, overwrite it with each other's responses.
+// Use CreateAndDoWithResponse or Response of APIError to get the rate limit of a particular call instead.
+func (c *Client) LastResponse() *Response {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lastResponse
+}
@@ -0,0 +1,99 @@
+package circleci
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// NewTLSConfig creates tls.Config which trusts the CA certificates in the PEM files in addition to the system ones.
+// This can be used with WithTLSConfig to connect to CircleCI server served with a certificate of private CA.
+func NewTLSConfig(caFiles ...string) (*tls.Config, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	for _, f := range caFiles {
+		pem, err := ioutil.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate is found in %s", f)
+		}
+	}
+	return &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}, nil
+}
+
+// Capabilities represents API features supported by the CircleCI installation the client connects to.
+type Capabilities struct {
+	APIv2    bool `json:"api_v2"`
+	APIv1    bool `json:"api_v1"`
+	Context  bool `json:"context"`
+	Pipeline bool `json:"pipeline"`
+	Webhook  bool `json:"webhook"`
+	Runner   bool `json:"runner"`
+}
+
+type capabilityProbe struct {
+	base      *url.URL
+	prefix    string
+	path      string
+	supported *bool
+}
+
+// ProbeCapabilities detects which API features the CircleCI installation supports.
+// CircleCI server may lack some features available in circleci.com depending on its version.
+// A feature is regarded as supported if the endpoint responds with 2xx, 400 Bad Request or 405 Method Not Allowed,
+// as the probe requests lack required parameters on purpose. Other status codes such as 404 Not Found mean unsupported,
+// except that 401, 403 and 5xx are returned as *APIError since they tell nothing about the feature.
+func (c *Client) ProbeCapabilities() (*Capabilities, error) {
+	caps := &Capabilities{}
+	probes := []capabilityProbe{
+		{c.BaseURL, c.pathPrefix, "/me", &caps.APIv2},
+		{c.BaseURL, c.v1PathPrefix, "/me", &caps.APIv1},
+		{c.BaseURL, c.pathPrefix, contextBasePath, &caps.Context},
+		{c.BaseURL, c.pathPrefix, pipelineBasePath, &caps.Pipeline},
+		{c.webhookURL(), c.pathPrefix, "/webhook", &caps.Webhook},
+		{c.runnerURL(), runnerPathPrefix, "/runner", &caps.Runner},
+	}
+
+	for _, p := range probes {
+		supported, err := c.probe(p.base, p.prefix, p.path)
+		if err != nil {
+			return nil, err
+		}
+		*p.supported = supported
+	}
+	return caps, nil
+}
+
+// probe reports whether the endpoint of the path exists by the status code of the GET request to it.
+// The request is sent as other API requests, i.e. with authentication, hooks and rate limit.
+func (c *Client) probe(base *url.URL, prefix, relPath string) (bool, error) {
+	u := strings.TrimRight(base.String(), "/") + path.Join(prefix, relPath)
+	req, err := c.NewRequest("GET", u, nil, nil)
+	if err != nil {
+		return false, err
+	}
+	_, err = c.doWithResponse(req, nil)
+	if err == nil {
+		return true, nil
+	}
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		return false, err
+	}
+	switch status := apiErr.HTTPStatusCode; {
+	case status == http.StatusBadRequest, status == http.StatusMethodNotAllowed:
+		return true, nil
+	case status == http.StatusUnauthorized, status == http.StatusForbidden, status >= 500:
+		return false, err
+	}
+	return false, nil
+}
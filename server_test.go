@@ -0,0 +1,73 @@
+package circleci_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+func TestClient_ProbeCapabilities(t *testing.T) {
+	mux := http.NewServeMux()
+	statuses := map[string]int{
+		"/api/v2/me":      http.StatusOK,
+		"/api/v1.1/me":    http.StatusOK,
+		"/api/v2/context": http.StatusBadRequest,
+		"/api/v2/webhook": http.StatusUnprocessableEntity,
+		"/api/v3/runner":  http.StatusMethodNotAllowed,
+	}
+	for p, status := range statuses {
+		status := status
+		mux.HandleFunc(p, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		})
+	}
+	// Runner and webhook APIs are expected on BaseURL set after the client is created.
+	c := newTestClient(t, mux)
+
+	caps, err := c.ProbeCapabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := circleci.Capabilities{APIv2: true, APIv1: true, Context: true, Runner: true}
+	if *caps != expected {
+		t.Errorf("Invalid capabilities. Expected: %+v, Actual: %+v", expected, *caps)
+	}
+}
+
+func TestClient_ProbeCapabilities_Error(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusForbidden, http.StatusInternalServerError, http.StatusBadGateway} {
+		status := status
+		c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		}))
+		caps, err := c.ProbeCapabilities()
+		if apiErr, ok := err.(*circleci.APIError); !ok || apiErr.HTTPStatusCode != status {
+			t.Errorf("Expected an error of %d. Actual: %+v, %v", status, caps, err)
+		}
+	}
+}
+
+func TestClient_ProbeCapabilities_Hooks(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Circle-Token") != "test_token" {
+			t.Errorf("Probe must be authenticated: %s", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	requests := 0
+	circleci.WithHook(&circleci.HookFuncs{Before: func(req *http.Request) *http.Request {
+		requests++
+		return req
+	}})(c)
+
+	if _, err := c.ProbeCapabilities(); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 6 {
+		t.Errorf("Hooks must observe every probe. Actual: %d", requests)
+	}
+	if r := c.LastResponse(); r == nil || r.StatusCode != http.StatusBadRequest {
+		t.Errorf("LastResponse must be the last probe. Actual: %+v", r)
+	}
+}
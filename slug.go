@@ -0,0 +1,175 @@
+package circleci
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Providers of project slug. Short names are used in normalized slugs.
+const (
+	ProviderGitHub    = "gh"
+	ProviderBitbucket = "bb"
+	ProviderGitLab    = "gitlab"
+	// ProviderCircleCI is used for standalone projects, e.g. GitLab and GitHub App projects,
+	// whose slugs are circleci/<org-id>/<project-id>.
+	ProviderCircleCI = "circleci"
+
+	webBaseURL = "https://app.circleci.com/pipelines"
+)
+
+var (
+	shortProviders = map[string]string{
+		"gh":        ProviderGitHub,
+		"github":    ProviderGitHub,
+		"bb":        ProviderBitbucket,
+		"bitbucket": ProviderBitbucket,
+		"gl":        ProviderGitLab,
+		"gitlab":    ProviderGitLab,
+		"circleci":  ProviderCircleCI,
+	}
+	longProviders = map[string]string{
+		ProviderGitHub:    "github",
+		ProviderBitbucket: "bitbucket",
+		ProviderGitLab:    "gitlab",
+		ProviderCircleCI:  "circleci",
+	}
+	vcsHosts = map[string]string{
+		"github.com":    ProviderGitHub,
+		"bitbucket.org": ProviderBitbucket,
+		"gitlab.com":    ProviderGitLab,
+	}
+	slugNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+	scpLikePattern  = regexp.MustCompile(`^(?:[A-Za-z0-9_.-]+@)?([A-Za-z0-9_.-]+):(.+)$`)
+)
+
+// Slug represents a project slug of CircleCI, i.e. <provider>/<org>/<repo>.
+// For standalone projects, Org and Repo hold the organization ID and the project ID.
+type Slug struct {
+	Provider string
+	Org      string
+	Repo     string
+}
+
+// ParseSlug parses a project slug such as gh/org/repo, github/org/repo or circleci/<org-id>/<project-id>.
+// Subgroups of GitLab are kept in Org as ParseVCSURL does, e.g. gitlab/group/subgroup/repo.
+// Escaped segments are unescaped. The returned Slug is normalized but not validated.
+func ParseSlug(s string) (Slug, error) {
+	parts := strings.Split(strings.Trim(s, "/"), "/")
+	if len(parts) > 3 && shortProviders[strings.ToLower(parts[0])] == ProviderGitLab {
+		parts = []string{parts[0], strings.Join(parts[1:len(parts)-1], "/"), parts[len(parts)-1]}
+	}
+	if len(parts) != 3 {
+		return Slug{}, fmt.Errorf("invalid project slug %q: expected <provider>/<org>/<repo>", s)
+	}
+	for i, p := range parts {
+		unescaped, err := url.PathUnescape(p)
+		if err != nil {
+			return Slug{}, fmt.Errorf("invalid project slug %q: %w", s, err)
+		}
+		parts[i] = unescaped
+	}
+	return Slug{Provider: parts[0], Org: parts[1], Repo: parts[2]}.Normalize(), nil
+}
+
+// ParseVCSURL parses a URL of a repository in GitHub, Bitbucket or GitLab into Slug,
+// e.g. https://github.com/org/repo, https://github.com/org/repo.git or git@github.com:org/repo.git.
+// Subgroups of GitLab are kept in Org.
+func ParseVCSURL(rawURL string) (Slug, error) {
+	var host, p string
+	if m := scpLikePattern.FindStringSubmatch(rawURL); m != nil && !strings.Contains(rawURL, "://") {
+		host, p = m[1], m[2]
+	} else {
+		u, err := url.Parse(rawURL)
+		if err != nil {
+			return Slug{}, err
+		}
+		host, p = u.Hostname(), u.Path
+	}
+
+	provider, ok := vcsHosts[strings.TrimPrefix(strings.ToLower(host), "www.")]
+	if !ok {
+		return Slug{}, fmt.Errorf("unsupported VCS host %q", host)
+	}
+	if k := strings.Index(p, "/-/"); k >= 0 {
+		// Drop trailing path such as /-/tree/main of a web URL of GitLab.
+		p = p[:k]
+	}
+	p = strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+	i := strings.LastIndex(p, "/")
+	if i <= 0 {
+		return Slug{}, fmt.Errorf("invalid repository URL %q", rawURL)
+	}
+	org, repo := p[:i], p[i+1:]
+	if provider != ProviderGitLab && strings.Contains(org, "/") {
+		// Drop trailing path such as /tree/main of a web URL.
+		segments := strings.Split(p, "/")
+		org, repo = segments[0], segments[1]
+	}
+	return Slug{Provider: provider, Org: org, Repo: repo}, nil
+}
+
+// Normalize returns the Slug with the provider in short name, e.g. github to gh.
+// Unknown providers are kept as they are.
+func (s Slug) Normalize() Slug {
+	if p, ok := shortProviders[strings.ToLower(s.Provider)]; ok {
+		s.Provider = p
+	}
+	return s
+}
+
+// Long returns the Slug with the provider in long name, e.g. gh to github, which API v1.1 requires.
+func (s Slug) Long() Slug {
+	s = s.Normalize()
+	if p, ok := longProviders[s.Provider]; ok {
+		s.Provider = p
+	}
+	return s
+}
+
+// IsStandalone reports whether the slug is of a standalone project, i.e. circleci/<org-id>/<project-id>.
+func (s Slug) IsStandalone() bool {
+	return s.Normalize().Provider == ProviderCircleCI
+}
+
+// Validate checks the slug is well-formed for its provider.
+// A GitLab slug such as gitlab/group/subgroup/repo, which ParseVCSURL returns, is valid as a name of the repository,
+// but API calls need the slug of the project in circleci/<org-id>/<project-id> form, shown in the project settings.
+func (s Slug) Validate() error {
+	n := s.Normalize()
+	switch n.Provider {
+	case ProviderGitHub, ProviderBitbucket, ProviderGitLab:
+		names := []string{n.Org, n.Repo}
+		if n.Provider == ProviderGitLab {
+			names = append(strings.Split(n.Org, "/"), n.Repo)
+		}
+		for _, name := range names {
+			if !slugNamePattern.MatchString(name) {
+				return fmt.Errorf("invalid project slug %q: invalid name %q", s.String(), name)
+			}
+		}
+	case ProviderCircleCI:
+		if !uuidPattern.MatchString(n.Org) || !uuidPattern.MatchString(n.Repo) {
+			return fmt.Errorf("invalid project slug %q: expected circleci/<org-id>/<project-id>", s.String())
+		}
+	default:
+		return fmt.Errorf("invalid project slug %q: unknown provider %q", s.String(), s.Provider)
+	}
+	return nil
+}
+
+// String returns the slug as it is, e.g. gh/org/repo.
+func (s Slug) String() string {
+	return s.Provider + "/" + s.Org + "/" + s.Repo
+}
+
+// PathEscaped returns the slug with each segment escaped to be put in a URL path.
+func (s Slug) PathEscaped() string {
+	return url.PathEscape(s.Provider) + "/" + url.PathEscape(s.Org) + "/" + url.PathEscape(s.Repo)
+}
+
+// WebURL returns the URL of the project's pipelines in the web UI of CircleCI.
+func (s Slug) WebURL() string {
+	return webBaseURL + "/" + s.Long().PathEscaped()
+}
@@ -0,0 +1,99 @@
+package circleci_test
+
+import (
+	"testing"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+func TestParseSlug(t *testing.T) {
+	tests := map[string]circleci.Slug{
+		"gh/ttyfky/go-circleci":      {Provider: "gh", Org: "ttyfky", Repo: "go-circleci"},
+		"github/ttyfky/go-circleci":  {Provider: "gh", Org: "ttyfky", Repo: "go-circleci"},
+		"/bitbucket/ttyfky/repo/":    {Provider: "bb", Org: "ttyfky", Repo: "repo"},
+		"circleci/org-id/project-id": {Provider: "circleci", Org: "org-id", Repo: "project-id"},
+		"gh/ttyfky/go%2Ecircleci":    {Provider: "gh", Org: "ttyfky", Repo: "go.circleci"},
+		"gitlab/group/subgroup/repo": {Provider: "gitlab", Org: "group/subgroup", Repo: "repo"},
+		"gl/group%2Fsubgroup/repo":   {Provider: "gitlab", Org: "group/subgroup", Repo: "repo"},
+	}
+	for s, expected := range tests {
+		actual, err := circleci.ParseSlug(s)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", s, err)
+			continue
+		}
+		if actual != expected {
+			t.Errorf("Invalid slug of %s. Expected: %+v, Actual: %+v", s, expected, actual)
+		}
+	}
+
+	if _, err := circleci.ParseSlug("gh/ttyfky"); err == nil {
+		t.Error("Expected an error for slug without repo")
+	}
+	if _, err := circleci.ParseSlug("gh/ttyfky/go-circleci/tree"); err == nil {
+		t.Error("Expected an error for nested slug of GitHub")
+	}
+}
+
+func TestParseVCSURL(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/ttyfky/go-circleci":              "gh/ttyfky/go-circleci",
+		"https://github.com/ttyfky/go-circleci/tree/main":    "gh/ttyfky/go-circleci",
+		"git@github.com:ttyfky/go-circleci.git":              "gh/ttyfky/go-circleci",
+		"ssh://git@bitbucket.org/ttyfky/repo.git":            "bb/ttyfky/repo",
+		"https://gitlab.com/group/subgroup/repo/-/tree/main": "gitlab/group/subgroup/repo",
+	}
+	for u, expected := range tests {
+		actual, err := circleci.ParseVCSURL(u)
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", u, err)
+			continue
+		}
+		if actual.String() != expected {
+			t.Errorf("Invalid slug of %s. Expected: %s, Actual: %s", u, expected, actual)
+		}
+		for _, s := range []string{actual.String(), actual.PathEscaped(), actual.Long().String()} {
+			parsed, err := circleci.ParseSlug(s)
+			if err != nil || parsed != actual.Normalize() {
+				t.Errorf("Slug of %s must round-trip from %s. Actual: %+v, %v", u, s, parsed, err)
+			}
+			if err := parsed.Validate(); err != nil {
+				t.Errorf("Slug of %s must be valid. Actual: %v", u, err)
+			}
+		}
+	}
+}
+
+func TestSlug_Validate(t *testing.T) {
+	valid := []circleci.Slug{
+		{Provider: "gh", Org: "ttyfky", Repo: "go-circleci"},
+		{Provider: "circleci", Org: "5034460f-c7c4-4c43-9457-de07e2029e7b", Repo: "c9b3b0a2-45bc-4f5b-9d3c-ec4a2b5a0c8e"},
+		{Provider: "gitlab", Org: "group", Repo: "repo"},
+		{Provider: "gitlab", Org: "group/subgroup", Repo: "repo"},
+	}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Unexpected error for %s: %v", s, err)
+		}
+	}
+	invalid := []circleci.Slug{
+		{Provider: "svn", Org: "ttyfky", Repo: "go-circleci"},
+		{Provider: "gh", Org: "tty fky", Repo: "go-circleci"},
+		{Provider: "circleci", Org: "org", Repo: "project"},
+		{Provider: "gitlab", Org: "group//subgroup", Repo: "repo"},
+		{Provider: "gh", Org: "group/subgroup", Repo: "repo"},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Expected an error for %s", s)
+		}
+	}
+}
+
+func TestSlug_WebURL(t *testing.T) {
+	s := circleci.Slug{Provider: "gh", Org: "ttyfky", Repo: "go-circleci"}
+	expected := "https://app.circleci.com/pipelines/github/ttyfky/go-circleci"
+	if actual := s.WebURL(); actual != expected {
+		t.Errorf("Invalid web URL. Expected: %s, Actual: %s", expected, actual)
+	}
+}
@@ -0,0 +1,307 @@
+package circleci
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Results of test Metadata as reported by CircleCI.
+const (
+	TestResultSuccess = "success"
+	TestResultFailure = "failure"
+	TestResultSkipped = "skipped"
+)
+
+// TestSuite is a named set of test Metadata. One suite is made per job.
+type TestSuite struct {
+	Name        string     `json:"name,omitempty"`
+	JobNumber   int        `json:"job_number,omitempty"`
+	ProjectSlug string     `json:"project_slug,omitempty"`
+	Tests       []Metadata `json:"tests,omitempty"`
+}
+
+// JobTestSuite gets all test metadata of a job as a TestSuite.
+func (c *Client) JobTestSuite(id, projectSlug string) (*TestSuite, error) {
+	tests, err := c.Job.ListTestMetadata(id, projectSlug)
+	if err != nil {
+		return nil, err
+	}
+	return &TestSuite{Name: id, ProjectSlug: projectSlug, Tests: tests}, nil
+}
+
+// WorkflowTestSuites gets all test metadata of the jobs in a workflow.
+// One TestSuite is returned per job which has a job number, i.e. approval jobs are skipped.
+func (c *Client) WorkflowTestSuites(workflowID string) ([]*TestSuite, error) {
+	jobs, err := c.Workflow.ListJobs(workflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	var suites []*TestSuite
+	for _, j := range jobs {
+		if j.JobNumber == 0 {
+			continue
+		}
+		tests, err := c.Job.ListTestMetadata(strconv.Itoa(j.JobNumber), j.ProjectSlug)
+		if err != nil {
+			return nil, err
+		}
+		suites = append(suites, &TestSuite{
+			Name:        j.Name,
+			JobNumber:   j.JobNumber,
+			ProjectSlug: j.ProjectSlug,
+			Tests:       tests,
+		})
+	}
+	return suites, nil
+}
+
+// testOutcome classifies result of a test into pass, fail or skip.
+// Results other than the known ones of pass and skip, e.g. error or an empty result, are regarded as failure
+// so that a broken test is not reported as passed.
+func testOutcome(m Metadata) string {
+	switch strings.ToLower(m.Result) {
+	case TestResultSuccess, "passed", "pass":
+		return TestResultSuccess
+	case TestResultSkipped, "skip":
+		return TestResultSkipped
+	default:
+		return TestResultFailure
+	}
+}
+
+func testName(m Metadata) string {
+	if m.Classname == "" {
+		return m.Name
+	}
+	return m.Classname + " " + m.Name
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Skipped  int               `xml:"skipped,attr"`
+	Time     string            `xml:"time,attr"`
+	Suites   []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	Time      string           `xml:"time,attr"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Classname string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr,omitempty"`
+	Body    string `xml:",chardata"`
+}
+
+func formatSeconds(s float64) string {
+	return strconv.FormatFloat(s, 'f', 3, 64)
+}
+
+// WriteJUnitXML writes the suites to w in JUnit XML format.
+func WriteJUnitXML(w io.Writer, suites []*TestSuite) error {
+	root := &junitTestSuites{}
+	var total float64
+	for _, s := range suites {
+		js := &junitTestSuite{Name: s.Name}
+		var suiteTime float64
+		for _, m := range s.Tests {
+			tc := &junitTestCase{
+				Classname: m.Classname,
+				Name:      m.Name,
+				File:      m.File,
+				Time:      formatSeconds(m.RunTime),
+			}
+			switch testOutcome(m) {
+			case TestResultFailure:
+				js.Failures++
+				tc.Failure = &junitFailure{Message: firstLine(m.Message), Body: m.Message}
+			case TestResultSkipped:
+				js.Skipped++
+				tc.Skipped = &struct{}{}
+			}
+			js.Tests++
+			suiteTime += m.RunTime
+			js.TestCases = append(js.TestCases, tc)
+		}
+		js.Time = formatSeconds(suiteTime)
+		root.Tests += js.Tests
+		root.Failures += js.Failures
+		root.Skipped += js.Skipped
+		total += suiteTime
+		root.Suites = append(root.Suites, js)
+	}
+	root.Time = formatSeconds(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteTAP writes the suites to w in TAP version 13 format.
+func WriteTAP(w io.Writer, suites []*TestSuite) error {
+	var count int
+	for _, s := range suites {
+		count += len(s.Tests)
+	}
+	if _, err := fmt.Fprintf(w, "TAP version 13\n1..%d\n", count); err != nil {
+		return err
+	}
+
+	n := 0
+	for _, s := range suites {
+		if len(s.Tests) > 0 {
+			if _, err := fmt.Fprintf(w, "# %s\n", tapEscape(s.Name)); err != nil {
+				return err
+			}
+		}
+		for _, m := range s.Tests {
+			n++
+			var err error
+			switch testOutcome(m) {
+			case TestResultSuccess:
+				_, err = fmt.Fprintf(w, "ok %d - %s\n", n, tapEscape(testName(m)))
+			case TestResultSkipped:
+				_, err = fmt.Fprintf(w, "ok %d - %s # SKIP\n", n, tapEscape(testName(m)))
+			default:
+				_, err = fmt.Fprintf(w, "not ok %d - %s\n", n, tapEscape(testName(m)))
+				if err == nil {
+					err = writeTAPDiagnostic(w, m)
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeTAPDiagnostic(w io.Writer, m Metadata) error {
+	var b strings.Builder
+	b.WriteString("  ---\n")
+	if m.File != "" {
+		fmt.Fprintf(&b, "  file: %s\n", strconv.Quote(m.File))
+	}
+	fmt.Fprintf(&b, "  duration_s: %s\n", formatSeconds(m.RunTime))
+	if m.Message != "" {
+		b.WriteString("  message: |\n")
+		message := strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(m.Message)
+		for _, l := range strings.Split(strings.TrimRight(message, "\n"), "\n") {
+			b.WriteString("    " + l + "\n")
+		}
+	}
+	b.WriteString("  ...\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// tapEscape escapes # and backslash, which start a directive and an escape in TAP,
+// and flattens line breaks to keep the text in one line.
+func tapEscape(s string) string {
+	return tapEscaper.Replace(s)
+}
+
+var tapEscaper = strings.NewReplacer(`\`, `\\`, "#", `\#`, "\r\n", " ", "\n", " ", "\r", " ")
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// TestSummary represents aggregated result of tests.
+type TestSummary struct {
+	Total   int             `json:"total"`
+	Passed  int             `json:"passed"`
+	Failed  int             `json:"failed"`
+	Skipped int             `json:"skipped"`
+	RunTime float64         `json:"run_time"`
+	Suites  []*SuiteSummary `json:"suites,omitempty"`
+	Failing []Metadata      `json:"failing,omitempty"`
+	Slowest []Metadata      `json:"slowest,omitempty"`
+}
+
+// SuiteSummary represents aggregated result of tests in a TestSuite.
+type SuiteSummary struct {
+	Name    string  `json:"name"`
+	Total   int     `json:"total"`
+	Passed  int     `json:"passed"`
+	Failed  int     `json:"failed"`
+	Skipped int     `json:"skipped"`
+	RunTime float64 `json:"run_time"`
+}
+
+// SummarizeTests aggregates the suites into a TestSummary.
+// Up to slowest tests are listed in the summary ordered by run time.
+func SummarizeTests(suites []*TestSuite, slowest int) *TestSummary {
+	sum := &TestSummary{}
+	var all []Metadata
+	for _, s := range suites {
+		ss := &SuiteSummary{Name: s.Name}
+		for _, m := range s.Tests {
+			ss.Total++
+			ss.RunTime += m.RunTime
+			switch testOutcome(m) {
+			case TestResultSuccess:
+				ss.Passed++
+			case TestResultSkipped:
+				ss.Skipped++
+			default:
+				ss.Failed++
+				sum.Failing = append(sum.Failing, m)
+			}
+			all = append(all, m)
+		}
+		sum.Total += ss.Total
+		sum.Passed += ss.Passed
+		sum.Failed += ss.Failed
+		sum.Skipped += ss.Skipped
+		sum.RunTime += ss.RunTime
+		sum.Suites = append(sum.Suites, ss)
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].RunTime > all[j].RunTime })
+	if slowest > len(all) {
+		slowest = len(all)
+	}
+	if slowest > 0 {
+		sum.Slowest = all[:slowest]
+	}
+	return sum
+}
+
+// WriteJSONSummary writes the summary of the suites to w in JSON format.
+func WriteJSONSummary(w io.Writer, suites []*TestSuite, slowest int) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(SummarizeTests(suites, slowest))
+}
@@ -0,0 +1,101 @@
+package circleci_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+func testSuites() []*circleci.TestSuite {
+	return []*circleci.TestSuite{
+		{
+			Name: "build",
+			Tests: []circleci.Metadata{
+				{Classname: "pkg", Name: "TestA", Result: "success", RunTime: 0.5},
+				{Classname: "pkg", Name: "TestB", Result: "failure", RunTime: 2, Message: "expected 1\ngot 2"},
+				{Classname: "pkg", Name: "TestC", Result: "skipped"},
+			},
+		},
+		{
+			Name: "lint",
+			Tests: []circleci.Metadata{
+				{Classname: "lint", Name: "vet", Result: "success", RunTime: 1},
+			},
+		},
+	}
+}
+
+func TestSummarizeTests(t *testing.T) {
+	sum := circleci.SummarizeTests(testSuites(), 2)
+	if sum.Total != 4 || sum.Passed != 2 || sum.Failed != 1 || sum.Skipped != 1 {
+		t.Errorf("Invalid counts. Actual: %+v", sum)
+	}
+	if sum.RunTime != 3.5 {
+		t.Errorf("Invalid run time. Expected: 3.5, Actual: %v", sum.RunTime)
+	}
+	if len(sum.Slowest) != 2 || sum.Slowest[0].Name != "TestB" || sum.Slowest[1].Name != "vet" {
+		t.Errorf("Invalid slowest tests. Actual: %+v", sum.Slowest)
+	}
+	if len(sum.Failing) != 1 || sum.Failing[0].Name != "TestB" {
+		t.Errorf("Invalid failing tests. Actual: %+v", sum.Failing)
+	}
+}
+
+func TestWriteJUnitXML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := circleci.WriteJUnitXML(&buf, testSuites()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		`<testsuites tests="4" failures="1" skipped="1" time="3.500">`,
+		`<testsuite name="build" tests="3" failures="1" skipped="1" time="2.500">`,
+		`<failure message="expected 1">expected 1&#xA;got 2</failure>`,
+		`<skipped></skipped>`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("JUnit XML does not contain %s. Actual:\n%s", expected, out)
+		}
+	}
+}
+
+func TestWriteTAP(t *testing.T) {
+	var buf bytes.Buffer
+	if err := circleci.WriteTAP(&buf, testSuites()); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, expected := range []string{
+		"TAP version 13\n1..4\n",
+		"ok 1 - pkg TestA\n",
+		"not ok 2 - pkg TestB\n",
+		"    got 2\n",
+		"ok 3 - pkg TestC # SKIP\n",
+		"ok 4 - lint vet\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("TAP does not contain %q. Actual:\n%s", expected, out)
+		}
+	}
+}
+
+func TestWriteTAP_Escape(t *testing.T) {
+	suites := []*circleci.TestSuite{{
+		Name: "build\n#2",
+		Tests: []circleci.Metadata{
+			{Name: "issue #1 \\ fixed\nnext", Result: "success"},
+			{Name: "crashed", Result: "error", Message: "panic\r\nline 2"},
+		},
+	}}
+	var buf bytes.Buffer
+	if err := circleci.WriteTAP(&buf, suites); err != nil {
+		t.Fatal(err)
+	}
+	expected := "TAP version 13\n1..2\n# build \\#2\nok 1 - issue \\#1 \\\\ fixed next\nnot ok 2 - crashed\n" +
+		"  ---\n  duration_s: 0.000\n  message: |\n    panic\n    line 2\n  ...\n"
+	if buf.String() != expected {
+		t.Errorf("Invalid TAP.\nExpected:\n%s\nActual:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,343 @@
+package circleci
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	organizationBasePath = "/organizations"
+
+	defaultUsageExportInterval = 10 * time.Second
+)
+
+// State of UsageExportJob.
+const (
+	UsageExportCreated    = "created"
+	UsageExportProcessing = "processing"
+	UsageExportCompleted  = "completed"
+	UsageExportFailed     = "failed"
+)
+
+// UsageService is an interface for Usage API, which exports usage of an organization as CSV files.
+type UsageService interface {
+	CreateExport(orgID string, opts *UsageExportOptions) (*UsageExportJob, error)
+	GetExport(orgID, jobID string) (*UsageExportJob, error)
+	WaitExport(ctx context.Context, orgID, jobID string) (*UsageExportJob, error)
+	Download(job *UsageExportJob) ([]*UsageRow, error)
+}
+
+// UsageOp handles communication with the usage related methods in the CircleCI API v2.
+type UsageOp struct {
+	client *Client
+}
+
+var _ UsageService = (*UsageOp)(nil)
+
+// UsageExportOptions represents the date range of a usage export.
+// CircleCI accepts a range of up to 32 days starting within the past year.
+// SharedOrgIDs are organizations sharing the plan of the organization to include.
+type UsageExportOptions struct {
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	SharedOrgIDs []string  `json:"shared_org_ids,omitempty"`
+}
+
+// UsageExportJob represents a job exporting usage. DownloadURLs are available once it is completed.
+type UsageExportJob struct {
+	ID           string    `json:"usage_export_job_id,omitempty"`
+	State        string    `json:"state,omitempty"`
+	Start        time.Time `json:"start,omitempty"`
+	End          time.Time `json:"end,omitempty"`
+	DownloadURLs []string  `json:"download_urls,omitempty"`
+	ErrorReason  string    `json:"error_reason,omitempty"`
+}
+
+// IsFinished reports whether the export is completed or failed.
+func (j *UsageExportJob) IsFinished() bool {
+	return j.State == UsageExportCompleted || j.State == UsageExportFailed
+}
+
+// CreateExport starts exporting usage of the organization.
+func (ps *UsageOp) CreateExport(orgID string, opts *UsageExportOptions) (*UsageExportJob, error) {
+	j := &UsageExportJob{}
+	err := ps.client.Post(usageExportPath(orgID), opts, j)
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// GetExport gets the export job.
+func (ps *UsageOp) GetExport(orgID, jobID string) (*UsageExportJob, error) {
+	j := &UsageExportJob{}
+	err := ps.client.Get(usageExportPath(orgID)+"/"+jobID, j, nil)
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// WaitExport polls the export job until it finishes or ctx is done. A failed export is returned with an error.
+func (ps *UsageOp) WaitExport(ctx context.Context, orgID, jobID string) (*UsageExportJob, error) {
+	for {
+		j, err := ps.GetExport(orgID, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if j.State == UsageExportFailed {
+			return j, fmt.Errorf("usage export %s failed: %s", jobID, j.ErrorReason)
+		}
+		if j.IsFinished() {
+			return j, nil
+		}
+		select {
+		case <-ctx.Done():
+			return j, ctx.Err()
+		case <-time.After(defaultUsageExportInterval):
+		}
+	}
+}
+
+// Download downloads the CSV files of the completed export and parses them.
+func (ps *UsageOp) Download(job *UsageExportJob) ([]*UsageRow, error) {
+	if job.State != UsageExportCompleted {
+		return nil, fmt.Errorf("usage export %s is %s", job.ID, job.State)
+	}
+	var rows []*UsageRow
+	for _, u := range job.DownloadURLs {
+		body, err := ps.client.openURL(u, false)
+		if err != nil {
+			return nil, err
+		}
+		rs, err := ParseUsageCSV(body)
+		body.Close()
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, rs...)
+	}
+	return rows, nil
+}
+
+func usageExportPath(orgID string) string {
+	return organizationBasePath + "/" + orgID + "/usage_export_job"
+}
+
+// UsageRow represents a job run in a usage export.
+type UsageRow struct {
+	OrganizationID  string
+	ProjectID       string
+	ProjectName     string
+	VCSName         string
+	Branch          string
+	PipelineID      string
+	PipelineNumber  int
+	WorkflowID      string
+	WorkflowName    string
+	JobID           string
+	JobName         string
+	JobRunNumber    int
+	JobRunStartedAt time.Time
+	JobRunStoppedAt time.Time
+	JobBuildStatus  string
+	ResourceClass   string
+	OperatingSystem string
+	Executor        string
+	Parallelism     int
+	JobRunSeconds   float64
+
+	ComputeCredits float64
+	DLCCredits     float64
+	UserCredits    float64
+	StorageCredits float64
+	NetworkCredits float64
+	TotalCredits   float64
+}
+
+// ComputeMinutes returns the minutes the job ran.
+func (r *UsageRow) ComputeMinutes() float64 {
+	return r.JobRunSeconds / 60
+}
+
+// ParseUsageCSV parses a CSV file of a usage export, which may be gzip-compressed.
+// Columns are found by the header, and columns not in UsageRow are ignored.
+func ParseUsageCSV(r io.Reader) ([]*UsageRow, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[strings.ToUpper(strings.TrimSpace(h))] = i
+	}
+
+	var rows []*UsageRow
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		p := &usageParser{columns: columns, record: record}
+		row := &UsageRow{
+			OrganizationID:  p.value("ORGANIZATION_ID"),
+			ProjectID:       p.value("PROJECT_ID"),
+			ProjectName:     p.value("PROJECT_NAME"),
+			VCSName:         p.value("VCS_NAME"),
+			Branch:          p.value("VCS_BRANCH"),
+			PipelineID:      p.value("PIPELINE_ID"),
+			PipelineNumber:  p.intValue("PIPELINE_NUMBER"),
+			WorkflowID:      p.value("WORKFLOW_ID"),
+			WorkflowName:    p.value("WORKFLOW_NAME"),
+			JobID:           p.value("JOB_ID"),
+			JobName:         p.value("JOB_NAME"),
+			JobRunNumber:    p.intValue("JOB_RUN_NUMBER"),
+			JobRunStartedAt: p.timeValue("JOB_RUN_STARTED_AT"),
+			JobRunStoppedAt: p.timeValue("JOB_RUN_STOPPED_AT"),
+			JobBuildStatus:  p.value("JOB_BUILD_STATUS"),
+			ResourceClass:   p.value("RESOURCE_CLASS"),
+			OperatingSystem: p.value("OPERATING_SYSTEM"),
+			Executor:        p.value("EXECUTOR"),
+			Parallelism:     p.intValue("PARALLELISM"),
+			JobRunSeconds:   p.floatValue("JOB_RUN_SECONDS"),
+			ComputeCredits:  p.floatValue("COMPUTE_CREDITS"),
+			DLCCredits:      p.floatValue("DLC_CREDITS"),
+			UserCredits:     p.floatValue("USER_CREDITS"),
+			StorageCredits:  p.floatValue("STORAGE_CREDITS"),
+			NetworkCredits:  p.floatValue("NETWORK_CREDITS"),
+			TotalCredits:    p.floatValue("TOTAL_CREDITS"),
+		}
+		if p.err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, p.err)
+		}
+		rows = append(rows, row)
+	}
+}
+
+// usageParser reads typed values of a record, keeping the first error.
+type usageParser struct {
+	columns map[string]int
+	record  []string
+	err     error
+}
+
+func (p *usageParser) value(column string) string {
+	i, ok := p.columns[column]
+	if !ok || i >= len(p.record) {
+		return ""
+	}
+	return strings.TrimSpace(p.record[i])
+}
+
+func (p *usageParser) intValue(column string) int {
+	s := p.value(column)
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("%s: %w", column, err)
+	}
+	return n
+}
+
+func (p *usageParser) floatValue(column string) float64 {
+	s := p.value(column)
+	if s == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("%s: %w", column, err)
+	}
+	return f
+}
+
+var usageTimeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999"}
+
+func (p *usageParser) timeValue(column string) time.Time {
+	s := p.value(column)
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range usageTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	if p.err == nil {
+		p.err = fmt.Errorf("%s: invalid time %q", column, s)
+	}
+	return time.Time{}
+}
+
+// UsageSummary represents usage aggregated by a key, e.g. a project.
+type UsageSummary struct {
+	Key            string  `json:"key"`
+	Credits        float64 `json:"credits"`
+	ComputeMinutes float64 `json:"compute_minutes"`
+	Jobs           int     `json:"jobs"`
+}
+
+// AggregateUsage sums up total credits and compute minutes of the rows by the key, sorted by credits from the largest.
+func AggregateUsage(rows []*UsageRow, key func(*UsageRow) string) []*UsageSummary {
+	m := map[string]*UsageSummary{}
+	var summaries []*UsageSummary
+	for _, r := range rows {
+		k := key(r)
+		s, ok := m[k]
+		if !ok {
+			s = &UsageSummary{Key: k}
+			m[k] = s
+			summaries = append(summaries, s)
+		}
+		s.Credits += r.TotalCredits
+		s.ComputeMinutes += r.ComputeMinutes()
+		s.Jobs++
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].Credits != summaries[j].Credits {
+			return summaries[i].Credits > summaries[j].Credits
+		}
+		return summaries[i].Key < summaries[j].Key
+	})
+	return summaries
+}
+
+// UsageByProject aggregates usage by project name.
+func UsageByProject(rows []*UsageRow) []*UsageSummary {
+	return AggregateUsage(rows, func(r *UsageRow) string { return r.ProjectName })
+}
+
+// UsageByResourceClass aggregates usage by resource class.
+func UsageByResourceClass(rows []*UsageRow) []*UsageSummary {
+	return AggregateUsage(rows, func(r *UsageRow) string { return r.ResourceClass })
+}
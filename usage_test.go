@@ -0,0 +1,103 @@
+package circleci_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+const usageCSV = `ORGANIZATION_ID,PROJECT_NAME,JOB_NAME,JOB_RUN_STARTED_AT,RESOURCE_CLASS,PARALLELISM,JOB_RUN_SECONDS,COMPUTE_CREDITS,TOTAL_CREDITS
+org,api,build,2021-03-01 10:00:00.000,medium,1,120,20,20
+org,api,test,2021-03-01 10:02:00.000,large,2,300,100,110
+org,web,build,2021-03-01 11:00:00.000,medium,1,60,10,10
+`
+
+func TestUsageOp(t *testing.T) {
+	var created *circleci.UsageExportOptions
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/organizations/org/usage_export_job", func(w http.ResponseWriter, r *http.Request) {
+		created = &circleci.UsageExportOptions{}
+		if err := json.NewDecoder(r.Body).Decode(created); err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(`{"usage_export_job_id": "job", "state": "created"}`))
+	})
+	var server string
+	mux.HandleFunc("/api/v2/organizations/org/usage_export_job/job", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"usage_export_job_id": "job",
+			"state":               "completed",
+			"download_urls":       []string{server + "/usage.csv.gz"},
+		})
+	})
+	mux.HandleFunc("/usage.csv.gz", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "" || r.Header.Get("Circle-Token") != "" {
+			t.Error("Pre-signed URL must not be requested with the token")
+		}
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(usageCSV))
+		gz.Close()
+	})
+	c := newTestClient(t, mux)
+	server = c.BaseURL.String()
+
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	job, err := c.Usage.CreateExport("org", &circleci.UsageExportOptions{Start: start, End: start.AddDate(0, 0, 7)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ID != "job" || !created.Start.Equal(start) {
+		t.Fatalf("Invalid export. Actual: %+v, %+v", job, created)
+	}
+
+	job, err = c.Usage.WaitExport(context.Background(), "org", job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := c.Usage.Download(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("Expected 3 rows. Actual: %d", len(rows))
+	}
+	if r := rows[1]; r.JobName != "test" || r.Parallelism != 2 || r.TotalCredits != 110 || r.ComputeMinutes() != 5 ||
+		!r.JobRunStartedAt.Equal(time.Date(2021, 3, 1, 10, 2, 0, 0, time.UTC)) {
+		t.Errorf("Invalid row. Actual: %+v", r)
+	}
+}
+
+func TestAggregateUsage(t *testing.T) {
+	rows, err := circleci.ParseUsageCSV(strings.NewReader(usageCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*circleci.UsageSummary{
+		{Key: "api", Credits: 130, ComputeMinutes: 7, Jobs: 2},
+		{Key: "web", Credits: 10, ComputeMinutes: 1, Jobs: 1},
+	}
+	if actual := circleci.UsageByProject(rows); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Invalid usage by project. Actual: %+v", actual)
+	}
+	expected = []*circleci.UsageSummary{
+		{Key: "large", Credits: 110, ComputeMinutes: 5, Jobs: 1},
+		{Key: "medium", Credits: 30, ComputeMinutes: 3, Jobs: 2},
+	}
+	if actual := circleci.UsageByResourceClass(rows); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Invalid usage by resource class. Actual: %+v", actual)
+	}
+
+	_, err = circleci.ParseUsageCSV(bytes.NewBufferString("TOTAL_CREDITS\nabc\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("Expected an error of the line. Actual: %v", err)
+	}
+}
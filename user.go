@@ -0,0 +1,67 @@
+package circleci
+
+const (
+	userBasePath = "/user"
+	meBasePath   = "/me"
+)
+
+// UserService is an interface for User API.
+type UserService interface {
+	Me() (*User, error)
+	Collaborations() ([]*Collaboration, error)
+	Get(id string) (*User, error)
+}
+
+// UserOp handles communication with the user related methods in the CircleCI API v2.
+type UserOp struct {
+	client *Client
+}
+
+var _ UserService = (*UserOp)(nil)
+
+// User represents a user in CircleCI.
+type User struct {
+	ID    string `json:"id,omitempty"`
+	Login string `json:"login,omitempty"`
+	Name  string `json:"name,omitempty"`
+}
+
+// Collaboration represents an organization the user is a member of.
+// Slug is the owner slug, e.g. gh/org, which is given to ContextService.List.
+type Collaboration struct {
+	ID      string `json:"id,omitempty"`
+	VcsType string `json:"vcs-type,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Avatar  string `json:"avatar,omitempty"`
+	Slug    string `json:"slug,omitempty"`
+}
+
+// Me gets the user of the token.
+func (ps *UserOp) Me() (*User, error) {
+	u := &User{}
+	err := ps.client.Get(meBasePath, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Collaborations lists the organizations the user of the token is a member of.
+func (ps *UserOp) Collaborations() ([]*Collaboration, error) {
+	var cs []*Collaboration
+	err := ps.client.Get(meBasePath+"/collaborations", &cs, nil)
+	if err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// Get gets the user.
+func (ps *UserOp) Get(id string) (*User, error) {
+	u := &User{}
+	err := ps.client.Get(userBasePath+"/"+id, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
@@ -0,0 +1,227 @@
+package circleci
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// V1Service is an interface for endpoints of API v1.1 which are not available in API v2 yet.
+type V1Service interface {
+	Projects() ([]*V1Project, error)
+	RecentBuilds(opts *BuildListOptions) ([]*BuildSummary, error)
+	ProjectBuilds(projectSlug string, opts *BuildListOptions) ([]*BuildSummary, error)
+	Build(projectSlug string, buildNum int) (*BuildDetail, error)
+	Retry(projectSlug string, buildNum int) (*BuildSummary, error)
+	RetryWithSSH(projectSlug string, buildNum int) (*BuildSummary, error)
+	AddSSHKey(projectSlug, hostname, privateKey string) error
+	DeleteSSHKey(projectSlug, hostname, fingerprint string) error
+	Follow(projectSlug string) (*FollowResult, error)
+	Unfollow(projectSlug string) (*FollowResult, error)
+	ClearCache(projectSlug string) (*CacheClearResult, error)
+}
+
+// V1Op handles communication with the methods in the CircleCI API v1.1.
+type V1Op struct {
+	client *Client
+}
+
+var _ V1Service = (*V1Op)(nil)
+
+// BuildListOptions represents options to list builds in API v1.1.
+// Filter is one of completed, successful, failed or running.
+type BuildListOptions struct {
+	Branch  string `url:"-"`
+	Limit   int    `url:"limit,omitempty"`
+	Offset  int    `url:"offset,omitempty"`
+	Filter  string `url:"filter,omitempty"`
+	Shallow bool   `url:"shallow,omitempty"`
+}
+
+// V1Project represents a followed project in API v1.1.
+type V1Project struct {
+	Username  string `json:"username,omitempty"`
+	Reponame  string `json:"reponame,omitempty"`
+	VcsURL    string `json:"vcs_url,omitempty"`
+	VcsType   string `json:"vcs_type,omitempty"`
+	Following bool   `json:"following,omitempty"`
+}
+
+// BuildSummary represents a build, i.e. a job, in API v1.1.
+type BuildSummary struct {
+	BuildNum        int       `json:"build_num,omitempty"`
+	BuildURL        string    `json:"build_url,omitempty"`
+	Username        string    `json:"username,omitempty"`
+	Reponame        string    `json:"reponame,omitempty"`
+	VcsType         string    `json:"vcs_type,omitempty"`
+	Lifecycle       string    `json:"lifecycle,omitempty"`
+	Status          string    `json:"status,omitempty"`
+	Outcome         string    `json:"outcome,omitempty"`
+	Branch          string    `json:"branch,omitempty"`
+	VcsRevision     string    `json:"vcs_revision,omitempty"`
+	Subject         string    `json:"subject,omitempty"`
+	Why             string    `json:"why,omitempty"`
+	SSHEnabled      bool      `json:"ssh_enabled,omitempty"`
+	QueuedAt        time.Time `json:"queued_at,omitempty"`
+	StartTime       time.Time `json:"start_time,omitempty"`
+	StopTime        time.Time `json:"stop_time,omitempty"`
+	BuildTimeMillis int64     `json:"build_time_millis,omitempty"`
+	Workflows       struct {
+		JobName      string `json:"job_name,omitempty"`
+		JobID        string `json:"job_id,omitempty"`
+		WorkflowName string `json:"workflow_name,omitempty"`
+		WorkflowID   string `json:"workflow_id,omitempty"`
+	} `json:"workflows,omitempty"`
+}
+
+// FollowResult represents result of following or unfollowing a project.
+type FollowResult struct {
+	Following  bool          `json:"following"`
+	FirstBuild *BuildSummary `json:"first_build,omitempty"`
+}
+
+// CacheClearResult represents result of clearing caches of a project.
+type CacheClearResult struct {
+	Status string `json:"status,omitempty"`
+}
+
+// Projects lists the projects the user follows.
+func (ps *V1Op) Projects() ([]*V1Project, error) {
+	var projects []*V1Project
+	err := ps.get("/projects", &projects, nil)
+	if err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
+
+// RecentBuilds lists recent builds across all the projects the user follows.
+func (ps *V1Op) RecentBuilds(opts *BuildListOptions) ([]*BuildSummary, error) {
+	var builds []*BuildSummary
+	err := ps.get("/recent-builds", &builds, opts)
+	if err != nil {
+		return nil, err
+	}
+	return builds, nil
+}
+
+// ProjectBuilds lists recent builds of the project, or of the branch if Branch is given in opts.
+func (ps *V1Op) ProjectBuilds(projectSlug string, opts *BuildListOptions) ([]*BuildSummary, error) {
+	var builds []*BuildSummary
+	path := v1ProjectPath(projectSlug)
+	if opts != nil && opts.Branch != "" {
+		path += "/tree/" + url.PathEscape(opts.Branch)
+	}
+	err := ps.get(path, &builds, opts)
+	if err != nil {
+		return nil, err
+	}
+	return builds, nil
+}
+
+// Build gets detail of the build with its steps.
+func (ps *V1Op) Build(projectSlug string, buildNum int) (*BuildDetail, error) {
+	b := &BuildDetail{}
+	err := ps.get(v1BuildPath(projectSlug, buildNum), b, nil)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Retry retries the build.
+func (ps *V1Op) Retry(projectSlug string, buildNum int) (*BuildSummary, error) {
+	b := &BuildSummary{}
+	err := ps.post(v1BuildPath(projectSlug, buildNum)+"/retry", nil, b)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// RetryWithSSH retries the build with SSH enabled.
+func (ps *V1Op) RetryWithSSH(projectSlug string, buildNum int) (*BuildSummary, error) {
+	b := &BuildSummary{}
+	err := ps.post(v1BuildPath(projectSlug, buildNum)+"/ssh", nil, b)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// AddSSHKey adds a private SSH key for the hostname to the project.
+func (ps *V1Op) AddSSHKey(projectSlug, hostname, privateKey string) error {
+	return ps.post(v1ProjectPath(projectSlug)+"/ssh-key", struct {
+		Hostname   string `json:"hostname"`
+		PrivateKey string `json:"private_key"`
+	}{
+		Hostname:   hostname,
+		PrivateKey: privateKey,
+	}, nil)
+}
+
+// DeleteSSHKey deletes the SSH key of the fingerprint for the hostname from the project.
+func (ps *V1Op) DeleteSSHKey(projectSlug, hostname, fingerprint string) error {
+	return ps.client.createAndDo(ps.client.v1PathPrefix, "DELETE", v1ProjectPath(projectSlug)+"/ssh-key", struct {
+		Hostname    string `json:"hostname"`
+		Fingerprint string `json:"fingerprint"`
+	}{
+		Hostname:    hostname,
+		Fingerprint: fingerprint,
+	}, nil, nil)
+}
+
+// Follow follows the project.
+func (ps *V1Op) Follow(projectSlug string) (*FollowResult, error) {
+	f := &FollowResult{}
+	err := ps.post(v1ProjectPath(projectSlug)+"/follow", nil, f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Unfollow unfollows the project.
+func (ps *V1Op) Unfollow(projectSlug string) (*FollowResult, error) {
+	f := &FollowResult{}
+	err := ps.post(v1ProjectPath(projectSlug)+"/unfollow", nil, f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// ClearCache clears the build caches of the project.
+func (ps *V1Op) ClearCache(projectSlug string) (*CacheClearResult, error) {
+	r := &CacheClearResult{}
+	err := ps.client.createAndDo(ps.client.v1PathPrefix, "DELETE", v1ProjectPath(projectSlug)+"/build-cache", nil, nil, r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (ps *V1Op) get(path string, resource, options interface{}) error {
+	return ps.client.createAndDo(ps.client.v1PathPrefix, "GET", path, nil, options, resource)
+}
+
+func (ps *V1Op) post(path string, data, resource interface{}) error {
+	return ps.client.createAndDo(ps.client.v1PathPrefix, "POST", path, data, nil, resource)
+}
+
+// v1ProjectSlug converts project slug of API v2 into the one of API v1.1, which does not accept short VCS names.
+func v1ProjectSlug(projectSlug string) string {
+	slug, err := ParseSlug(projectSlug)
+	if err != nil {
+		return projectSlug
+	}
+	return slug.Long().String()
+}
+
+func v1ProjectPath(projectSlug string) string {
+	return projectBasePath + "/" + v1ProjectSlug(projectSlug)
+}
+
+func v1BuildPath(projectSlug string, buildNum int) string {
+	return v1ProjectPath(projectSlug) + "/" + strconv.Itoa(buildNum)
+}
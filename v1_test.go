@@ -0,0 +1,131 @@
+package circleci_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+func TestV1Op(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		call     func(c *circleci.Client) error
+		method   string
+		path     string
+		body     string
+	}{
+		{
+			name:     "Projects",
+			response: `[]`,
+			call:     func(c *circleci.Client) error { _, err := c.V1.Projects(); return err },
+			method:   "GET",
+			path:     "/api/v1.1/projects",
+		},
+		{
+			name:     "RecentBuilds",
+			response: `[]`,
+			call: func(c *circleci.Client) error {
+				_, err := c.V1.RecentBuilds(&circleci.BuildListOptions{Limit: 5, Filter: "failed"})
+				return err
+			},
+			method: "GET",
+			path:   "/api/v1.1/recent-builds?filter=failed&limit=5",
+		},
+		{
+			name:     "ProjectBuilds",
+			response: `[]`,
+			call: func(c *circleci.Client) error {
+				_, err := c.V1.ProjectBuilds("gh/org/repo", &circleci.BuildListOptions{Branch: "feature/x", Offset: 10})
+				return err
+			},
+			method: "GET",
+			path:   "/api/v1.1/project/github/org/repo/tree/feature%2Fx?offset=10",
+		},
+		{
+			name:     "Build",
+			response: `{}`,
+			call:     func(c *circleci.Client) error { _, err := c.V1.Build("bb/org/repo", 12); return err },
+			method:   "GET",
+			path:     "/api/v1.1/project/bitbucket/org/repo/12",
+		},
+		{
+			name:     "Retry",
+			response: `{}`,
+			call:     func(c *circleci.Client) error { _, err := c.V1.Retry("gh/org/repo", 12); return err },
+			method:   "POST",
+			path:     "/api/v1.1/project/github/org/repo/12/retry",
+		},
+		{
+			name:     "RetryWithSSH",
+			response: `{}`,
+			call:     func(c *circleci.Client) error { _, err := c.V1.RetryWithSSH("github/org/repo", 12); return err },
+			method:   "POST",
+			path:     "/api/v1.1/project/github/org/repo/12/ssh",
+		},
+		{
+			name:     "AddSSHKey",
+			response: ``,
+			call:     func(c *circleci.Client) error { return c.V1.AddSSHKey("gh/org/repo", "example.com", "KEY") },
+			method:   "POST",
+			path:     "/api/v1.1/project/github/org/repo/ssh-key",
+			body:     `{"hostname":"example.com","private_key":"KEY"}`,
+		},
+		{
+			name:     "DeleteSSHKey",
+			response: ``,
+			call:     func(c *circleci.Client) error { return c.V1.DeleteSSHKey("gh/org/repo", "example.com", "aa:bb") },
+			method:   "DELETE",
+			path:     "/api/v1.1/project/github/org/repo/ssh-key",
+			body:     `{"hostname":"example.com","fingerprint":"aa:bb"}`,
+		},
+		{
+			name:     "Follow",
+			response: `{"following": true}`,
+			call:     func(c *circleci.Client) error { _, err := c.V1.Follow("gh/org/repo"); return err },
+			method:   "POST",
+			path:     "/api/v1.1/project/github/org/repo/follow",
+		},
+		{
+			name:     "Unfollow",
+			response: `{"following": false}`,
+			call:     func(c *circleci.Client) error { _, err := c.V1.Unfollow("gh/org/repo"); return err },
+			method:   "POST",
+			path:     "/api/v1.1/project/github/org/repo/unfollow",
+		},
+		{
+			name:     "ClearCache",
+			response: `{"status": "build dependency caches deleted"}`,
+			call:     func(c *circleci.Client) error { _, err := c.V1.ClearCache("gh/org/repo"); return err },
+			method:   "DELETE",
+			path:     "/api/v1.1/project/github/org/repo/build-cache",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var method, path, body string
+			c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method, path = r.Method, r.URL.EscapedPath()
+				if r.URL.RawQuery != "" {
+					path += "?" + r.URL.RawQuery
+				}
+				b, _ := ioutil.ReadAll(r.Body)
+				body = strings.TrimSpace(string(b))
+				w.Write([]byte(tt.response))
+			}))
+
+			if err := tt.call(c); err != nil {
+				t.Fatal(err)
+			}
+			if method != tt.method || path != tt.path {
+				t.Errorf("Invalid request.\nExpected: %s %s\nActual:   %s %s", tt.method, tt.path, method, path)
+			}
+			if tt.body != "" && body != tt.body {
+				t.Errorf("Invalid body.\nExpected: %s\nActual:   %s", tt.body, body)
+			}
+		})
+	}
+}
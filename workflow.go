@@ -1,6 +1,10 @@
 package circleci
 
-import "time"
+import (
+	"fmt"
+	"path"
+	"time"
+)
 
 const workflowBasePath = "/workflow"
 
@@ -8,9 +12,12 @@ const workflowBasePath = "/workflow"
 type WorkflowService interface {
 	Get(id string) (*Workflow, error)
 	Approve(id, approvalReqID string) (*Message, error)
+	ApprovePending(id string, patterns ...string) (*ApprovalResult, error)
 	Cancel(id string) (*Message, error)
 	GetJobs(id string) (*WorkflowJobs, error)
+	ListJobs(id string) ([]WorkflowJob, error)
 	Rerun(id string, jobIDs []string, fromFailed bool) (*Message, error)
+	RerunWithOptions(id string, opts *RerunOptions) (*RerunResult, error)
 }
 
 // WorkflowOp handles communication with the project related methods in the CircleCI API v2.
@@ -36,23 +43,26 @@ type Workflow struct {
 	StoppedAt      time.Time `json:"stopped_at,omitempty"`
 }
 
+// WorkflowJob represents a job belongs to a Workflow.
+type WorkflowJob struct {
+	CanceledBy        string      `json:"canceled_by,omitempty"`
+	Dependencies      []string    `json:"dependencies,omitempty"`
+	JobNumber         int         `json:"job_number,omitempty"`
+	ID                string      `json:"id,omitempty"`
+	StartedAt         time.Time   `json:"started_at,omitempty"`
+	Name              string      `json:"name,omitempty"`
+	ApprovedBy        string      `json:"approved_by,omitempty"`
+	ProjectSlug       string      `json:"project_slug,omitempty"`
+	Status            interface{} `json:"status,omitempty"`
+	Type              string      `json:"type,omitempty"`
+	StoppedAt         time.Time   `json:"stopped_at,omitempty"`
+	ApprovalRequestID string      `json:"approval_request_id,omitempty"`
+}
+
 // WorkflowJobs is jobs belongs to a Workflow.
 type WorkflowJobs struct {
-	Items []struct {
-		CanceledBy        string      `json:"canceled_by,omitempty"`
-		Dependencies      []string    `json:"dependencies,omitempty"`
-		JobNumber         int         `json:"job_number,omitempty"`
-		ID                string      `json:"id,omitempty"`
-		StartedAt         time.Time   `json:"started_at,omitempty"`
-		Name              string      `json:"name,omitempty"`
-		ApprovedBy        string      `json:"approved_by,omitempty"`
-		ProjectSlug       string      `json:"project_slug,omitempty"`
-		Status            interface{} `json:"status,omitempty"`
-		Type              string      `json:"type,omitempty"`
-		StoppedAt         time.Time   `json:"stopped_at,omitempty"`
-		ApprovalRequestID string      `json:"approval_request_id,omitempty"`
-	} `json:"items,omitempty"`
-	NextPageToken string `json:"next_page_token,omitempty"`
+	Items         []WorkflowJob `json:"items,omitempty"`
+	NextPageToken string        `json:"next_page_token,omitempty"`
 }
 
 // RerunJob is a payload to send when rerunning jobs in Workflow.
@@ -60,6 +70,33 @@ type WorkflowJobs struct {
 type RerunJob struct {
 	Jobs       []string `json:"jobs,omitempty"`
 	FromFailed bool     `json:"from_failed,omitempty"`
+	EnableSSH  bool     `json:"enable_ssh,omitempty"`
+	SparseTree bool     `json:"sparse_tree,omitempty"`
+}
+
+// RerunOptions represents options to rerun a Workflow.
+// Jobs can be specified by IDs in JobIDs and/or by names in JobNames.
+type RerunOptions struct {
+	JobIDs     []string
+	JobNames   []string
+	FromFailed bool
+	EnableSSH  bool
+	SparseTree bool
+}
+
+// RerunResult represents result of rerunning a Workflow.
+type RerunResult struct {
+	WorkflowID string `json:"workflow_id,omitempty"`
+}
+
+// ApprovalResult represents result of approving approval jobs in a Workflow.
+// Each field holds names of the approval jobs, or the patterns for Missing.
+// NotReady holds the approval jobs which can not be approved yet, e.g. blocked by the jobs they require.
+type ApprovalResult struct {
+	Approved        []string `json:"approved,omitempty"`
+	AlreadyApproved []string `json:"already_approved,omitempty"`
+	NotReady        []string `json:"not_ready,omitempty"`
+	Missing         []string `json:"missing,omitempty"`
 }
 
 // Get gets detail of workflow.
@@ -84,6 +121,65 @@ func (ps *WorkflowOp) Approve(id, approvalReqID string) (*Message, error) {
 	return m, nil
 }
 
+// ApprovePending approves approval jobs on hold in the workflow.
+// Only the jobs whose name matches any of patterns in path.Match syntax are approved if patterns are given.
+// Matched approval jobs neither on hold nor approved are returned in NotReady,
+// and patterns which matched no approval job are returned in Missing of ApprovalResult.
+func (ps *WorkflowOp) ApprovePending(id string, patterns ...string) (*ApprovalResult, error) {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p, err)
+		}
+	}
+
+	jobs, err := ps.ListJobs(id)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ApprovalResult{}
+	matched := make([]bool, len(patterns))
+	for i := range jobs {
+		j := &jobs[i]
+		if j.Type != jobTypeApproval || !matchAny(patterns, j.Name, matched) {
+			continue
+		}
+		switch jobStatus(j) {
+		case JobStatusOnHold:
+			if _, err := ps.Approve(id, j.ApprovalRequestID); err != nil {
+				return res, fmt.Errorf("failed to approve %q: %w", j.Name, err)
+			}
+			res.Approved = append(res.Approved, j.Name)
+		case JobStatusSuccess:
+			res.AlreadyApproved = append(res.AlreadyApproved, j.Name)
+		default:
+			res.NotReady = append(res.NotReady, j.Name)
+		}
+	}
+	for i, p := range patterns {
+		if !matched[i] {
+			res.Missing = append(res.Missing, p)
+		}
+	}
+	return res, nil
+}
+
+// matchAny reports whether name matches any of patterns, marking the matched patterns.
+// Any name matches when no pattern is given.
+func matchAny(patterns []string, name string, matched []bool) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	ok := false
+	for i, p := range patterns {
+		if m, _ := path.Match(p, name); m {
+			matched[i] = true
+			ok = true
+		}
+	}
+	return ok
+}
+
 // Cancel cancels given workflow.
 func (ps *WorkflowOp) Cancel(id string) (*Message, error) {
 	m := &Message{}
@@ -99,14 +195,33 @@ func (ps *WorkflowOp) Cancel(id string) (*Message, error) {
 func (ps *WorkflowOp) GetJobs(id string) (*WorkflowJobs, error) {
 	wj := &WorkflowJobs{}
 	path := workflowBasePath + "/" + id + "/job"
-	err := ps.client.Post(path, nil, wj)
+	err := ps.client.Get(path, wj, nil)
 	if err != nil {
 		return nil, err
 	}
 	return wj, nil
 }
 
-// Rerun cancels given workflow
+// ListJobs get all jobs in the workflow by following every page.
+func (ps *WorkflowOp) ListJobs(id string) ([]WorkflowJob, error) {
+	var jobs []WorkflowJob
+	path := workflowBasePath + "/" + id + "/job"
+	opts := &PageOptions{}
+	for {
+		wj := &WorkflowJobs{}
+		err := ps.client.Get(path, wj, opts)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, wj.Items...)
+		if wj.NextPageToken == "" {
+			return jobs, nil
+		}
+		opts.PageToken = wj.NextPageToken
+	}
+}
+
+// Rerun reruns given workflow.
 func (ps *WorkflowOp) Rerun(id string, jobIDs []string, fromFailed bool) (*Message, error) {
 	m := &Message{}
 	path := workflowBasePath + "/" + id + "/rerun"
@@ -119,3 +234,42 @@ func (ps *WorkflowOp) Rerun(id string, jobIDs []string, fromFailed bool) (*Messa
 	}
 	return m, nil
 }
+
+// RerunWithOptions reruns given workflow and returns ID of the new workflow.
+// Names in JobNames of opts are resolved to job IDs from the jobs in the workflow.
+func (ps *WorkflowOp) RerunWithOptions(id string, opts *RerunOptions) (*RerunResult, error) {
+	if opts == nil {
+		opts = &RerunOptions{}
+	}
+	payload := &RerunJob{
+		Jobs:       append([]string(nil), opts.JobIDs...),
+		FromFailed: opts.FromFailed,
+		EnableSSH:  opts.EnableSSH,
+		SparseTree: opts.SparseTree,
+	}
+
+	if len(opts.JobNames) > 0 {
+		jobs, err := ps.ListJobs(id)
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[string]string, len(jobs))
+		for _, j := range jobs {
+			ids[j.Name] = j.ID
+		}
+		for _, name := range opts.JobNames {
+			jobID, ok := ids[name]
+			if !ok {
+				return nil, fmt.Errorf("job %q is not found in workflow %s", name, id)
+			}
+			payload.Jobs = append(payload.Jobs, jobID)
+		}
+	}
+
+	rr := &RerunResult{}
+	err := ps.client.Post(workflowBasePath+"/"+id+"/rerun", payload, rr)
+	if err != nil {
+		return nil, err
+	}
+	return rr, nil
+}
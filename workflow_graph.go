@@ -0,0 +1,332 @@
+package circleci
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Status of a job in a workflow.
+const (
+	JobStatusSuccess  = "success"
+	JobStatusRunning  = "running"
+	JobStatusNotRun   = "not_run"
+	JobStatusFailed   = "failed"
+	JobStatusQueued   = "queued"
+	JobStatusOnHold   = "on_hold"
+	JobStatusBlocked  = "blocked"
+	JobStatusCanceled = "canceled"
+
+	jobTypeApproval = "approval"
+)
+
+func jobStatus(j *WorkflowJob) string {
+	if j.Status == nil {
+		return ""
+	}
+	return fmt.Sprint(j.Status)
+}
+
+// JobNode is a job in JobGraph.
+type JobNode struct {
+	Job          *WorkflowJob
+	Dependencies []*JobNode
+	Dependents   []*JobNode
+}
+
+// Status returns status of the job.
+func (n *JobNode) Status() string {
+	return jobStatus(n.Job)
+}
+
+// IsApproval reports whether the job is an approval job.
+func (n *JobNode) IsApproval() bool {
+	return n.Job.Type == jobTypeApproval
+}
+
+// Duration returns how long the job took. Zero is returned when the job has not started or stopped.
+func (n *JobNode) Duration() time.Duration {
+	if n.Job.StartedAt.IsZero() || n.Job.StoppedAt.IsZero() {
+		return 0
+	}
+	return n.Job.StoppedAt.Sub(n.Job.StartedAt)
+}
+
+// JobGraph is a directed acyclic graph of the jobs in a workflow built from their dependencies.
+type JobGraph struct {
+	// Nodes are in the order of given jobs.
+	Nodes []*JobNode
+	byID  map[string]*JobNode
+}
+
+// NewJobGraph builds JobGraph from jobs in a workflow.
+// Jobs are linked by the IDs in Dependencies, thus all dependent jobs must be given.
+func NewJobGraph(jobs []WorkflowJob) (*JobGraph, error) {
+	g := &JobGraph{byID: make(map[string]*JobNode, len(jobs))}
+	for i := range jobs {
+		j := &jobs[i]
+		if _, ok := g.byID[j.ID]; ok {
+			return nil, fmt.Errorf("duplicated job ID %q", j.ID)
+		}
+		n := &JobNode{Job: j}
+		g.Nodes = append(g.Nodes, n)
+		g.byID[j.ID] = n
+	}
+	for _, n := range g.Nodes {
+		for _, id := range n.Job.Dependencies {
+			d, ok := g.byID[id]
+			if !ok {
+				return nil, fmt.Errorf("job %q depends on unknown job ID %q", n.Job.Name, id)
+			}
+			n.Dependencies = append(n.Dependencies, d)
+			d.Dependents = append(d.Dependents, n)
+		}
+	}
+	return g, nil
+}
+
+// Node returns JobNode of the given job ID. Nil is returned if not found.
+func (g *JobGraph) Node(id string) *JobNode {
+	return g.byID[id]
+}
+
+// Roots returns jobs which do not have dependencies.
+func (g *JobGraph) Roots() []*JobNode {
+	var roots []*JobNode
+	for _, n := range g.Nodes {
+		if len(n.Dependencies) == 0 {
+			roots = append(roots, n)
+		}
+	}
+	return roots
+}
+
+// TopologicalOrder returns jobs ordered so that every job comes after its dependencies.
+// Jobs without order between them keep the order of given jobs.
+// An error is returned when the dependencies have a cycle.
+func (g *JobGraph) TopologicalOrder() ([]*JobNode, error) {
+	index := make(map[*JobNode]int, len(g.Nodes))
+	inDegree := make(map[*JobNode]int, len(g.Nodes))
+	var ready []*JobNode
+	for i, n := range g.Nodes {
+		index[n] = i
+		inDegree[n] = len(n.Dependencies)
+		if inDegree[n] == 0 {
+			ready = append(ready, n)
+		}
+	}
+
+	order := make([]*JobNode, 0, len(g.Nodes))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(i, j int) bool { return index[ready[i]] < index[ready[j]] })
+		n := ready[0]
+		ready = ready[1:]
+		order = append(order, n)
+		for _, d := range n.Dependents {
+			inDegree[d]--
+			if inDegree[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+
+	if len(order) != len(g.Nodes) {
+		var cyclic []string
+		for _, n := range g.Nodes {
+			if inDegree[n] > 0 {
+				cyclic = append(cyclic, n.Job.Name)
+			}
+		}
+		return nil, fmt.Errorf("job dependencies have a cycle among %s", strings.Join(cyclic, ", "))
+	}
+	return order, nil
+}
+
+// CriticalPath returns the chain of jobs which determined when the workflow finished,
+// along with the elapsed time from the start of the first job to the end of the last job in the chain.
+// The chain is traced back from the job finished last, following the dependency finished last at each step.
+// Jobs still running are regarded as finishing at now.
+func (g *JobGraph) CriticalPath(now time.Time) ([]*JobNode, time.Duration) {
+	end := func(n *JobNode) time.Time {
+		switch {
+		case !n.Job.StoppedAt.IsZero():
+			return n.Job.StoppedAt
+		case !n.Job.StartedAt.IsZero():
+			return now
+		}
+		return time.Time{}
+	}
+
+	var last *JobNode
+	for _, n := range g.Nodes {
+		if e := end(n); !e.IsZero() && (last == nil || e.After(end(last))) {
+			last = n
+		}
+	}
+	if last == nil {
+		return nil, 0
+	}
+
+	path := []*JobNode{last}
+	visited := map[*JobNode]bool{last: true}
+	for n := last; ; {
+		var gate *JobNode
+		for _, d := range n.Dependencies {
+			if e := end(d); !e.IsZero() && !visited[d] && (gate == nil || e.After(end(gate))) {
+				gate = d
+			}
+		}
+		if gate == nil {
+			break
+		}
+		visited[gate] = true
+		path = append(path, gate)
+		n = gate
+	}
+
+	// Reverse to make the path start from the first job.
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+
+	first := path[0]
+	start := first.Job.StartedAt
+	if start.IsZero() {
+		start = end(first)
+	}
+	return path, end(last).Sub(start)
+}
+
+// Blocked returns jobs waiting for their dependencies.
+func (g *JobGraph) Blocked() []*JobNode {
+	var blocked []*JobNode
+	for _, n := range g.Nodes {
+		if n.Status() == JobStatusBlocked {
+			blocked = append(blocked, n)
+		}
+	}
+	return blocked
+}
+
+// PendingApprovals returns approval jobs waiting to be approved.
+func (g *JobGraph) PendingApprovals() []*JobNode {
+	var pending []*JobNode
+	for _, n := range g.Nodes {
+		if n.IsApproval() && n.Status() == JobStatusOnHold {
+			pending = append(pending, n)
+		}
+	}
+	return pending
+}
+
+// BlockedBy returns the jobs which are the root causes of the given job being blocked,
+// e.g. approvals on hold, running jobs or failed jobs among its transitive dependencies.
+func (g *JobGraph) BlockedBy(n *JobNode) []*JobNode {
+	var causes []*JobNode
+	visited := map[*JobNode]bool{}
+	var walk func(n *JobNode)
+	walk = func(n *JobNode) {
+		for _, d := range n.Dependencies {
+			if visited[d] {
+				continue
+			}
+			visited[d] = true
+			switch d.Status() {
+			case JobStatusSuccess:
+			case JobStatusBlocked:
+				walk(d)
+			default:
+				causes = append(causes, d)
+			}
+		}
+	}
+	walk(n)
+	return causes
+}
+
+func graphLabel(n *JobNode) string {
+	label := n.Job.Name
+	if s := n.Status(); s != "" {
+		label += "\n" + s
+	}
+	if d := n.Duration(); d > 0 {
+		label += " (" + d.Round(time.Second).String() + ")"
+	}
+	return label
+}
+
+func dotColor(status string) string {
+	switch status {
+	case JobStatusSuccess:
+		return "palegreen"
+	case JobStatusFailed:
+		return "lightcoral"
+	case JobStatusRunning, JobStatusQueued:
+		return "lightskyblue"
+	case JobStatusOnHold:
+		return "plum"
+	case JobStatusBlocked:
+		return "lightgrey"
+	default:
+		return "white"
+	}
+}
+
+// DOT returns the graph in Graphviz DOT language.
+func (g *JobGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph workflow {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=filled];\n")
+	for _, n := range g.Nodes {
+		shape := ""
+		if n.IsApproval() {
+			shape = ", shape=hexagon"
+		}
+		fmt.Fprintf(&b, "  %q [label=%q, fillcolor=%s%s];\n", n.Job.ID, graphLabel(n), dotColor(n.Status()), shape)
+	}
+	for _, n := range g.Nodes {
+		for _, d := range n.Dependencies {
+			fmt.Fprintf(&b, "  %q -> %q;\n", d.Job.ID, n.Job.ID)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid returns the graph in Mermaid flowchart syntax.
+func (g *JobGraph) Mermaid() string {
+	ids := make(map[*JobNode]string, len(g.Nodes))
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for i, n := range g.Nodes {
+		id := fmt.Sprintf("job%d", i)
+		ids[n] = id
+		label := strings.Replace(graphLabel(n), "\n", "<br/>", -1)
+		label = strings.Replace(label, `"`, "#quot;", -1)
+		if n.IsApproval() {
+			fmt.Fprintf(&b, "  %s{{\"%s\"}}\n", id, label)
+		} else {
+			fmt.Fprintf(&b, "  %s[\"%s\"]\n", id, label)
+		}
+	}
+	for _, n := range g.Nodes {
+		for _, d := range n.Dependencies {
+			fmt.Fprintf(&b, "  %s --> %s\n", ids[d], ids[n])
+		}
+	}
+	defined := map[string]bool{}
+	for _, n := range g.Nodes {
+		s := n.Status()
+		if s == "" {
+			continue
+		}
+		if !defined[s] {
+			defined[s] = true
+			fmt.Fprintf(&b, "  classDef %s fill:%s\n", s, dotColor(s))
+		}
+		fmt.Fprintf(&b, "  class %s %s\n", ids[n], s)
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package circleci_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+func testWorkflowJobs() []circleci.WorkflowJob {
+	base := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	at := func(m int) time.Time { return base.Add(time.Duration(m) * time.Minute) }
+	return []circleci.WorkflowJob{
+		{ID: "d", Name: "deploy", Status: "blocked", Dependencies: []string{"h"}},
+		{ID: "b", Name: "build", Status: "success", StartedAt: at(0), StoppedAt: at(5)},
+		{ID: "l", Name: "lint", Status: "success", StartedAt: at(0), StoppedAt: at(2)},
+		{ID: "t", Name: "test", Status: "success", StartedAt: at(6), StoppedAt: at(20), Dependencies: []string{"b"}},
+		{ID: "h", Name: "hold", Type: "approval", Status: "on_hold", Dependencies: []string{"t", "l"}},
+	}
+}
+
+func TestJobGraph_TopologicalOrder(t *testing.T) {
+	g, err := circleci.NewJobGraph(testWorkflowJobs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := g.TopologicalOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, n := range order {
+		names = append(names, n.Job.Name)
+	}
+	expected := "build,lint,test,hold,deploy"
+	if actual := strings.Join(names, ","); actual != expected {
+		t.Errorf("Invalid order. Expected: %s, Actual: %s", expected, actual)
+	}
+}
+
+func TestJobGraph_Cycle(t *testing.T) {
+	g, err := circleci.NewJobGraph([]circleci.WorkflowJob{
+		{ID: "a", Name: "a", Dependencies: []string{"b"}},
+		{ID: "b", Name: "b", Dependencies: []string{"a"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.TopologicalOrder(); err == nil {
+		t.Error("Expected an error for cyclic dependencies")
+	}
+}
+
+func TestJobGraph_CriticalPath(t *testing.T) {
+	g, err := circleci.NewJobGraph(testWorkflowJobs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, d := g.CriticalPath(time.Now())
+	if len(path) != 2 || path[0].Job.Name != "build" || path[1].Job.Name != "test" {
+		t.Errorf("Invalid critical path. Actual: %v", path)
+	}
+	if d != 20*time.Minute {
+		t.Errorf("Invalid duration. Expected: 20m, Actual: %s", d)
+	}
+}
+
+func TestJobGraph_BlockedBy(t *testing.T) {
+	g, err := circleci.NewJobGraph(testWorkflowJobs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	causes := g.BlockedBy(g.Node("d"))
+	if len(causes) != 1 || causes[0].Job.Name != "hold" {
+		t.Errorf("Invalid causes. Actual: %v", causes)
+	}
+	if pending := g.PendingApprovals(); len(pending) != 1 || pending[0].Job.ID != "h" {
+		t.Errorf("Invalid pending approvals. Actual: %v", pending)
+	}
+	if dot := g.DOT(); !strings.Contains(dot, `"t" -> "h";`) {
+		t.Errorf("DOT does not contain edge. Actual:\n%s", dot)
+	}
+}
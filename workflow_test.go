@@ -0,0 +1,101 @@
+package circleci_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/ttyfky/go-circleci"
+)
+
+func newTestClient(t *testing.T, handler http.Handler) *circleci.Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := circleci.NewClient("test_token")
+	c.BaseURL = u
+	return c
+}
+
+func TestWorkflowOp_ApprovePending(t *testing.T) {
+	var approved []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/workflow/wf/job", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items": [
+			{"id": "1", "name": "build", "type": "build", "status": "success"},
+			{"id": "2", "name": "hold-staging", "type": "approval", "status": "success", "approval_request_id": "a2"},
+			{"id": "3", "name": "hold-prod", "type": "approval", "status": "on_hold", "approval_request_id": "a3"},
+			{"id": "4", "name": "hold-qa", "type": "approval", "status": "on_hold", "approval_request_id": "a4"},
+			{"id": "5", "name": "hold-perf", "type": "approval", "status": "blocked", "approval_request_id": "a5"}
+		]}`)
+	})
+	mux.HandleFunc("/api/v2/workflow/wf/approve/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Invalid method. Expected: POST, Actual: %s", r.Method)
+		}
+		approved = append(approved, r.URL.Path)
+		fmt.Fprint(w, `{"message": "Accepted."}`)
+	})
+	c := newTestClient(t, mux)
+
+	res, err := c.Workflow.ApprovePending("wf", "hold-s*", "hold-p*", "deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &circleci.ApprovalResult{
+		Approved:        []string{"hold-prod"},
+		AlreadyApproved: []string{"hold-staging"},
+		NotReady:        []string{"hold-perf"},
+		Missing:         []string{"deploy"},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Invalid result. Expected: %+v, Actual: %+v", expected, res)
+	}
+	if !reflect.DeepEqual(approved, []string{"/api/v2/workflow/wf/approve/a3"}) {
+		t.Errorf("Invalid approve requests. Actual: %v", approved)
+	}
+}
+
+func TestWorkflowOp_RerunWithOptions(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v2/workflow/wf/job", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"items": [{"id": "j1", "name": "build"}, {"id": "j2", "name": "test"}]}`)
+	})
+	mux.HandleFunc("/api/v2/workflow/wf/rerun", func(w http.ResponseWriter, r *http.Request) {
+		var payload circleci.RerunJob
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		expected := circleci.RerunJob{Jobs: []string{"j0", "j2"}, EnableSSH: true, SparseTree: true}
+		if !reflect.DeepEqual(payload, expected) {
+			t.Errorf("Invalid payload. Expected: %+v, Actual: %+v", expected, payload)
+		}
+		fmt.Fprint(w, `{"workflow_id": "new-wf"}`)
+	})
+	c := newTestClient(t, mux)
+
+	res, err := c.Workflow.RerunWithOptions("wf", &circleci.RerunOptions{
+		JobIDs:     []string{"j0"},
+		JobNames:   []string{"test"},
+		EnableSSH:  true,
+		SparseTree: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.WorkflowID != "new-wf" {
+		t.Errorf("Invalid workflow ID. Expected: new-wf, Actual: %s", res.WorkflowID)
+	}
+
+	if _, err := c.Workflow.RerunWithOptions("wf", &circleci.RerunOptions{JobNames: []string{"deploy"}}); err == nil {
+		t.Error("Expected an error for unknown job name")
+	}
+}